@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cricklet/chessgo/internal/book"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) < 2 || Contains(args, "help") {
+		fmt.Println("usage:")
+		fmt.Println(" > book [max-plies=N] [min-weight=N] <output.bin> <games.pgn...>")
+		fmt.Println("eg:")
+		fmt.Println(" > book max-plies=16 data/book.bin games/*.pgn")
+		fmt.Println("the book can be played with 'setoption name BookFile value data/book.bin'")
+		return
+	}
+
+	options := book.BuildOptions{}
+	paths := []string{}
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			paths = append(paths, arg)
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			exitWithError(err)
+		}
+		switch key {
+		case "max-plies":
+			options.MaxPlies = Some(n)
+		case "min-weight":
+			options.MinWeight = Some(n)
+		default:
+			exitWithError(fmt.Errorf("unknown option '%v'", key))
+		}
+	}
+
+	if len(paths) < 2 {
+		exitWithError(fmt.Errorf("missing the output or pgn files"))
+	}
+	output, pgnPaths := paths[0], paths[1:]
+
+	games := []book.PgnGame{}
+	for _, path := range pgnPaths {
+		file, err := os.Open(path)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		read, readErr := book.ReadPgn(file)
+		file.Close()
+		if !IsNil(readErr) {
+			exitWithError(readErr)
+		}
+		games = append(games, read...)
+	}
+
+	b, err := book.Build(games, options)
+	if IsNil(err) {
+		err = b.Save(output)
+	}
+	if !IsNil(err) {
+		exitWithError(err)
+	}
+	fmt.Println("wrote", len(b.Entries()), "entries from", len(games), "games to", output)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cricklet/chessgo/internal/chessgo"
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
+)
+
+// _skillAnchorElo is the rating given to skill level 0. The other levels are
+// rated relative to it by playing each level against the next one.
+const _skillAnchorElo = 500
+
+// skillPairResult is the score of `Level` playing against `Level + 1`
+type skillPairResult struct {
+	Level  int `json:"level"`
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+}
+
+func (r skillPairResult) games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// eloDifference is how much stronger `Level + 1` is than `Level`. A pair that
+// never scores is treated as if it scored half a point, so the difference
+// stays finite.
+func (r skillPairResult) eloDifference() int {
+	games := float64(r.games())
+	if games == 0 {
+		return 0
+	}
+	score := (float64(r.Wins) + 0.5*float64(r.Draws)) / games
+	score = math.Max(0.5/games, math.Min(1-0.5/games, score))
+	return int(math.Round(-400 * math.Log10(score/(1-score))))
+}
+
+type skillCalibration struct {
+	Pairs []skillPairResult `json:"pairs"`
+	Elos  []int             `json:"elos"`
+}
+
+func (c *skillCalibration) computeElos() {
+	c.Elos = []int{_skillAnchorElo}
+	for _, pair := range c.Pairs {
+		c.Elos = append(c.Elos, Last(c.Elos)+pair.eloDifference())
+	}
+}
+
+func unmarshalSkillCalibration(jsonPath string, calibration *skillCalibration) Error {
+	exists, err := Exists(jsonPath)
+	if !IsNil(err) || !exists {
+		return err
+	}
+	input, err := WrapReturn(os.ReadFile(jsonPath))
+	if !IsNil(err) {
+		return err
+	}
+	return Wrap(json.Unmarshal(input, calibration))
+}
+
+func marshalSkillCalibration(jsonPath string, calibration *skillCalibration) Error {
+	output, err := json.MarshalIndent(calibration, "", "  ")
+	if !IsNil(err) {
+		return Wrap(err)
+	}
+	return Wrap(os.WriteFile(jsonPath, output, 0644))
+}
+
+// playSkillGame plays `white` against `black` from the starting position and
+// returns the score for white
+func playSkillGame(white int, black int) (float32, Error) {
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	players := [2]chessgo.ChessGoRunner{
+		chessgo.NewChessGoRunner(chessgo.ChessGoOptions{SkillLevel: Some(white)}),
+		chessgo.NewChessGoRunner(chessgo.ChessGoOptions{SkillLevel: Some(black)}),
+	}
+	for i := range players {
+		err := players[i].SetupPosition(Position{Fen: fen, Moves: []string{}})
+		if !IsNil(err) {
+			return 0.5, err
+		}
+	}
+
+	referee := &players[White]
+	history := map[string]int{}
+
+	for i := 0; i < 400; i++ {
+		player := referee.Player()
+
+		// The skill level limits how deep the search goes
+		move, _, _, err := players[player].Search(SearchParams{Depth: Some(100)})
+		if !IsNil(err) {
+			return 0.5, err
+		}
+		if move.IsEmpty() {
+			return 0.5, Errorf("level %v didn't find a move in %v", []int{white, black}[player], referee.FenString())
+		}
+
+		for i := range players {
+			err = players[i].PerformMoveFromString(move.Value())
+			if !IsNil(err) {
+				return 0.5, err
+			}
+		}
+
+		boardString := game.FenStringForBoard(referee.Board())
+		history[boardString]++
+		if history[boardString] >= 3 {
+			return 0.5, NilError
+		}
+
+		noValidMoves, err := referee.NoValidMoves()
+		if !IsNil(err) {
+			return 0.5, err
+		}
+		if noValidMoves {
+			if !referee.PlayerIsInCheck() {
+				return 0.5, NilError
+			} else if player == White {
+				return 1, NilError
+			} else {
+				return 0, NilError
+			}
+		}
+
+		if referee.DrawClock() >= 100 {
+			return 0.5, NilError
+		}
+	}
+
+	return 0.5, NilError
+}
+
+// CalibrateSkillMain rates the skill levels for search.SkillFromElo, eg
+// `go run ./cmd/elo calibrateSkill games=40`. Results are saved after each
+// game so that the calibration can be resumed.
+func CalibrateSkillMain(args []string) {
+	gamesPerPair := 40
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "games=") {
+			games, err := WrapReturn(strconv.Atoi(arg[len("games="):]))
+			if !IsNil(err) {
+				panic(err)
+			}
+			gamesPerPair = games
+		}
+	}
+
+	jsonPath := RootDir() + "/data/skill_calibration.json"
+
+	calibration := skillCalibration{}
+	err := unmarshalSkillCalibration(jsonPath, &calibration)
+	if !IsNil(err) {
+		panic(err)
+	}
+	for len(calibration.Pairs) < search.MaxSkillLevel-1 {
+		calibration.Pairs = append(calibration.Pairs, skillPairResult{Level: len(calibration.Pairs)})
+	}
+
+	for i := range calibration.Pairs {
+		pair := &calibration.Pairs[i]
+		for pair.games() < gamesPerPair {
+			// Alternate colors so that neither level always moves first
+			var score float32
+			if pair.games()%2 == 0 {
+				score, err = playSkillGame(pair.Level, pair.Level+1)
+			} else {
+				score, err = playSkillGame(pair.Level+1, pair.Level)
+				score = 1 - score
+			}
+			if !IsNil(err) {
+				panic(err)
+			}
+
+			if score == 1 {
+				pair.Wins++
+			} else if score == 0 {
+				pair.Losses++
+			} else {
+				pair.Draws++
+			}
+
+			logger.Printf("skill-%v vs skill-%v: +%v =%v -%v\n",
+				pair.Level, pair.Level+1, pair.Wins, pair.Draws, pair.Losses)
+
+			calibration.computeElos()
+			err = marshalSkillCalibration(jsonPath, &calibration)
+			if !IsNil(err) {
+				panic(err)
+			}
+		}
+	}
+
+	calibration.computeElos()
+	fmt.Println(strings.Join(MapSlice(calibration.Elos, func(elo int) string {
+		return fmt.Sprint(elo)
+	}), ", "))
+}
@@ -135,7 +135,12 @@ func runGame(binaryPath string, opt1 string, opt2 string) (float32, []int, Error
 		opt1, opt2, binaryPath))
 	logger.SetFooter(currentSuffix, _footerCurrent)
 
-	result, err := PlayBinaries(player1, player2, &runner, func() {
+	ponder := [2]bool{
+		Contains(strings.Split(opt1, " "), _ponderFlag),
+		Contains(strings.Split(opt2, " "), _ponderFlag),
+	}
+
+	result, err := PlayBinaries(player1, player2, &runner, ponder, func() {
 		player := runner.Player()
 
 		pgnString := fmt.Sprintf("%v\n%v", runner.PgnFromMoveHistory(), runner.FenString())
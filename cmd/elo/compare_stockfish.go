@@ -143,6 +143,7 @@ func playGame(
 	stockfishElo int,
 	opponent *binary.BinaryRunner,
 	runner *ChessGoRunner,
+	opponentPonders bool,
 	fen string,
 ) (stockfishResult, Error) {
 	Run(stockfish, "isready", Some("readyok"))
@@ -163,7 +164,7 @@ func playGame(
 		logger.SetFooter(runner.Board().Unicode(), _footerBoard)
 	}
 
-	result, err := PlayBinaries(stockfish, opponent, runner, updateFooter)
+	result, err := PlayBinaries(stockfish, opponent, runner, [2]bool{false, opponentPonders}, updateFooter)
 	updateFooter()
 
 	if !IsNil(err) {
@@ -233,7 +234,7 @@ func playGameBinaries(
 	defer opponent.Close()
 
 	var result stockfishResult
-	result, err = playGame(stockfish, stockfishElo, opponent, &runner, fen)
+	result, err = playGame(stockfish, stockfishElo, opponent, &runner, Contains(binaryArgs, _ponderFlag), fen)
 	if !IsNil(err) {
 		panic(err)
 	}
@@ -19,6 +19,8 @@ func main() {
 	args := os.Args[1:]
 	if len(args) > 0 && args[0] == "compareStockfish" {
 		CompareStockfishMain(args[1:])
+	} else if len(args) > 0 && args[0] == "calibrateSkill" {
+		CalibrateSkillMain(args[1:])
 	} else {
 		CompareChessGo(args)
 	}
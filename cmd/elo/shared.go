@@ -21,6 +21,20 @@ const _footerHistory = 8
 
 var logger = NewLiveLogger()
 
+// _ponderFlag is passed to binaries that should think on their opponent's
+// time. The binary ignores it, PlayBinaries sends "go ponder" instead.
+const _ponderFlag = "ponder"
+
+// _ponderMoveTime is used instead of "stop" for engines that ponder. Otherwise,
+// a late "stop" could end the next ponder search.
+const _ponderMoveTime = 1000
+
+// ponderState tracks a binary that thinks on its opponent's time
+type ponderState struct {
+	// The opponent move that the binary is currently pondering on
+	expected Optional[string]
+}
+
 func MakeDirIfMissing(dir string) Error {
 	_, err := os.Stat(dir)
 	if IsNil(err) {
@@ -82,7 +96,59 @@ func findMoveInOutput(output []string) (string, Error) {
 	return "", Errorf("couldn't find bestmove in output %v", output)
 }
 
-func Search(player Player, binary *binary.BinaryRunner, fen string, moveHistory []string, expectedFen string) []string {
+func findPonderMoveInOutput(output []string) Optional[string] {
+	bestMoveString := FindInSlice(output, func(v string) bool {
+		return strings.HasPrefix(v, "bestmove ")
+	})
+	if bestMoveString.HasValue() {
+		fields := strings.Fields(bestMoveString.Value())
+		if len(fields) == 4 && fields[2] == "ponder" {
+			return Some(fields[3])
+		}
+	}
+	return Empty[string]()
+}
+
+func Search(player Player, binary *binary.BinaryRunner, fen string, moveHistory []string, expectedFen string, ponder Optional[*ponderState]) []string {
+	results := []string{}
+
+	if ponder.HasValue() && ponder.Value().expected.HasValue() {
+		expected := ponder.Value().expected.Value()
+		ponder.Value().expected = Empty[string]()
+
+		if len(moveHistory) > 0 && Last(moveHistory) == expected {
+			results = Run(binary, "ponderhit", Some("bestmove"))
+		} else {
+			// Throw away the search on the wrong move
+			Run(binary, "stop", Some("bestmove"))
+		}
+	}
+
+	if len(results) == 0 {
+		results = searchPosition(binary, fen, moveHistory, expectedFen, ponder.HasValue())
+	}
+
+	move, err := findMoveInOutput(results)
+	if !IsNil(err) {
+		panic(err)
+	}
+	moveHistory = append(moveHistory, move)
+
+	logger.Printf("%v (%v) > %v\n", binary.CmdName(), player.String(), move)
+
+	if ponder.HasValue() {
+		ponderMove := findPonderMoveInOutput(results)
+		if ponderMove.HasValue() {
+			RunAsync(binary, fmt.Sprintf("position fen %v moves %v %v", fen, strings.Join(moveHistory, " "), ponderMove.Value()))
+			RunAsync(binary, fmt.Sprintf("go ponder movetime %v", _ponderMoveTime))
+			ponder.Value().expected = ponderMove
+		}
+	}
+
+	return moveHistory
+}
+
+func searchPosition(binary *binary.BinaryRunner, fen string, moveHistory []string, expectedFen string, ponder bool) []string {
 	fenInput := fmt.Sprintf("position fen %v moves %v", fen, strings.Join(moveHistory, " "))
 	RunAsync(binary, fenInput)
 
@@ -101,19 +167,15 @@ func Search(player Player, binary *binary.BinaryRunner, fen string, moveHistory
 		}
 	}
 
-	results, err := RunThenStop(binary, "go", time.Millisecond*1000, "stop", Some("bestmove"))
-	if !IsNil(err) {
-		panic(err)
+	if ponder {
+		return Run(binary, fmt.Sprintf("go movetime %v", _ponderMoveTime), Some("bestmove"))
 	}
-	move, err := findMoveInOutput(results)
+
+	results, err := RunThenStop(binary, "go", time.Millisecond*1000, "stop", Some("bestmove"))
 	if !IsNil(err) {
 		panic(err)
 	}
-	moveHistory = append(moveHistory, move)
-
-	logger.Printf("%v (%v) > %v\n", binary.CmdName(), player.String(), move)
-
-	return moveHistory
+	return results
 }
 
 type Evaluator struct {
@@ -171,8 +233,11 @@ func (e *Evaluator) Evaluate(fen string) (int, Error) {
 	return score, NilError
 }
 
+// PlayBinaries plays a game between two UCI binaries. `ponder` is indexed by
+// player and enables pondering for that binary.
 func PlayBinaries(player0 *binary.BinaryRunner, player1 *binary.BinaryRunner,
 	runner *chessgo.ChessGoRunner,
+	ponder [2]bool,
 	callback func(),
 ) (float32, Error) {
 	var err Error
@@ -188,6 +253,13 @@ func PlayBinaries(player0 *binary.BinaryRunner, player1 *binary.BinaryRunner,
 		player1: Black,
 	}
 
+	ponderStates := [2]Optional[*ponderState]{}
+	for player, enabled := range ponder {
+		if enabled {
+			ponderStates[player] = Some(&ponderState{})
+		}
+	}
+
 	nextBinary := player0
 	if runner.Player() == Black {
 		nextBinary = player1
@@ -203,7 +275,8 @@ func PlayBinaries(player0 *binary.BinaryRunner, player1 *binary.BinaryRunner,
 			nextBinary = player0
 		}
 
-		moveHistory = Search(binaryToPlayer[currentBinary], currentBinary, runner.StartFen, moveHistory, runner.FenString())
+		player := binaryToPlayer[currentBinary]
+		moveHistory = Search(player, currentBinary, runner.StartFen, moveHistory, runner.FenString(), ponderStates[player])
 		if Last(moveHistory) == "forfeit" {
 			if currentBinary == player0 {
 				return 1, NilError
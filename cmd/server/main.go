@@ -8,11 +8,13 @@ import (
 	"os"
 	"runtime/debug"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cricklet/chessgo/internal/chessgo"
 	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
 	"github.com/cricklet/chessgo/internal/stockfish"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -103,6 +105,22 @@ func (t PlayerType) String() string {
 	}
 }
 
+// PlayerSettingFromString reads eg "user", "stockfish" or "gopher". The gopher can
+// be weakened to an Elo, eg "gopher@1200".
+func PlayerSettingFromString(s string) (PlayerType, Optional[int]) {
+	name, eloString, found := strings.Cut(s, "@")
+	playerType := PlayerTypeFromString(name)
+	if !found || playerType != ChessGo {
+		return playerType, Empty[int]()
+	}
+
+	elo, err := strconv.Atoi(eloString)
+	if err != nil {
+		return Unknown, Empty[int]()
+	}
+	return playerType, Some(elo)
+}
+
 func PlayerTypeFromString(s string) PlayerType {
 	switch s {
 	case "user":
@@ -127,6 +145,7 @@ func main() {
 
 	var ws = func(w http.ResponseWriter, r *http.Request) {
 		playerTypes := [2]PlayerType{User, User}
+		playerElos := [2]Optional[int]{}
 		ready := false
 
 		c, err := upgrader.Upgrade(w, r, nil)
@@ -197,6 +216,9 @@ func main() {
 				log(fmt.Sprintf("gopher: %v", message))
 			},
 		}
+		chessGoRunner.SetOnIteration(func(result search.SearchResult) {
+			log(fmt.Sprintf("gopher: %v", result))
+		})
 
 		var finalizeUpdate = func(update UpdateToWeb) {
 			update.FenString = chessGoRunner.FenString()
@@ -220,41 +242,105 @@ func main() {
 			}
 		}
 
-		var performMove = func() bool {
-			if !ready {
-				return false
+		// Searches run in the background so that new messages can interrupt
+		// them. stateMutex guards everything below, the search goroutine
+		// only touches the game once it holds it again.
+		var stateMutex sync.Mutex
+		searchGeneration := 0
+		var searchDone chan struct{}
+
+		// abortSearch stops the running search and waits for it to exit. Its
+		// move is thrown away. Must be called with stateMutex held.
+		var abortSearch = func() {
+			if searchDone == nil {
+				return
+			}
+
+			searchGeneration++
+			chessGoRunner.Stop()
+			<-searchDone
+			searchDone = nil
+		}
+
+		var startSearch = func() {
+			if !ready || searchDone != nil {
+				return
 			}
 			if playerTypes[chessGoRunner.Player()] == User {
-				return false
+				return
 			}
 
-			runner := runnerForPlayer(chessGoRunner.Player())
+			params := SearchParams{Duration: Some(time.Second * 3)}
 
-			err := runner.PerformMoves(chessGoRunner.StartFen, chessGoRunner.MoveHistory())
-			if !IsNil(err) {
-				logger.Println("setup: ", err)
-				return false
-			}
+			var search func() (Optional[string], Error)
+			if playerTypes[chessGoRunner.Player()] == ChessGo {
+				elo := playerElos[chessGoRunner.Player()]
+				chessGoRunner.SetLimitStrength(elo.HasValue())
+				if elo.HasValue() {
+					chessGoRunner.SetElo(elo.Value())
+				}
 
-			bestMove, _, _, err := runner.Search(SearchParams{Duration: Some(time.Second * 3)})
-			if !IsNil(err) {
-				logger.Println("search: ", err)
-				return false
+				err := chessGoRunner.SearchAsync(params)
+				if !IsNil(err) {
+					logger.Println("search: ", err)
+					return
+				}
+
+				search = func() (Optional[string], Error) {
+					bestMove, _, _, _, err := chessGoRunner.Wait()
+					return bestMove, err
+				}
+			} else {
+				runner := runnerForPlayer(chessGoRunner.Player())
+				startFen, moves := chessGoRunner.StartFen, chessGoRunner.MoveHistory()
+
+				search = func() (Optional[string], Error) {
+					err := runner.PerformMoves(startFen, moves)
+					if !IsNil(err) {
+						return Empty[string](), Errorf("setup: %w", err)
+					}
+
+					bestMove, _, _, err := runner.Search(params)
+					return bestMove, err
+				}
 			}
 
-			if bestMove.HasValue() {
+			generation := searchGeneration
+			done := make(chan struct{})
+			searchDone = done
+
+			go func() {
+				bestMove, err := search()
+				close(done)
+
+				stateMutex.Lock()
+				defer stateMutex.Unlock()
+
+				if generation != searchGeneration {
+					// The position changed while searching
+					return
+				}
+				searchDone = nil
+
+				if !IsNil(err) {
+					logger.Println("search: ", err)
+					return
+				}
+
+				if bestMove.IsEmpty() {
+					logger.Println("no move found")
+					return
+				}
+
 				logger.Println("search: ", bestMove.Value())
-				err := chessGoRunner.PerformMoveFromString(bestMove.Value())
+				err = chessGoRunner.PerformMoveFromString(bestMove.Value())
 				if !IsNil(err) {
 					logger.Println("perform: ", bestMove.Value(), err)
-					return false
+					return
 				}
-			} else {
-				logger.Println("no move found")
-				return false
-			}
 
-			return true
+				finalizeUpdate(UpdateToWeb{})
+			}()
 		}
 
 		var handleMessageFromWeb = func(bytes []byte) {
@@ -265,10 +351,15 @@ func main() {
 			}
 			logger.Println("received", message)
 
+			stateMutex.Lock()
+			defer stateMutex.Unlock()
+
 			var update UpdateToWeb
 			shouldUpdate := false
 
 			if message.NewFen != nil {
+				abortSearch()
+
 				err := chessGoRunner.SetupPosition(Position{
 					Fen:   *message.NewFen,
 					Moves: []string{},
@@ -287,9 +378,13 @@ func main() {
 					}
 				}
 			} else if message.WhitePlayer != nil {
-				playerTypes[White] = PlayerTypeFromString(*message.WhitePlayer)
+				abortSearch()
+				playerTypes[White], playerElos[White] = PlayerSettingFromString(*message.WhitePlayer)
 			} else if message.BlackPlayer != nil {
-				playerTypes[Black] = PlayerTypeFromString(*message.BlackPlayer)
+				abortSearch()
+				playerTypes[Black], playerElos[Black] = PlayerSettingFromString(*message.BlackPlayer)
+			} else if message.Selection != nil && searchDone != nil {
+				// The search is using the board, the user can't move yet
 			} else if message.Selection != nil {
 				if *message.Selection != "" {
 					update.Selection = *message.Selection
@@ -301,12 +396,16 @@ func main() {
 				}
 				shouldUpdate = true
 			} else if message.Move != nil {
+				abortSearch()
+
 				err := chessGoRunner.PerformMoveFromString(*message.Move)
 				if !IsNil(err) {
 					logger.Println("perform: ", message.Move, err) // FUTURE reset
 				}
 				shouldUpdate = true
 			} else if message.Rewind != nil {
+				abortSearch()
+
 				err := chessGoRunner.Rewind(*message.Rewind)
 				if !IsNil(err) {
 					logger.Println("rewind: ", message.Rewind, err) // FUTURE reset
@@ -319,9 +418,11 @@ func main() {
 				}
 			}
 
-			if shouldUpdate || (ready && performMove()) {
+			if shouldUpdate {
 				finalizeUpdate(update)
 			}
+
+			startSearch()
 		}
 
 		defer c.Close()
@@ -334,6 +435,10 @@ func main() {
 				handleMessageFromWeb(message)
 			}
 		}
+
+		stateMutex.Lock()
+		abortSearch()
+		stateMutex.Unlock()
 	}
 
 	var index = func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/tablebase"
+)
+
+var defaultMaterials = []string{"KQK", "KRK", "KPK", "KBNK", "KRKP"}
+
+func main() {
+	args := os.Args[1:]
+	if Contains(args, "help") {
+		fmt.Println("usage:")
+		fmt.Println(" > tablebase [material...]")
+		fmt.Println("eg:")
+		fmt.Println(" > tablebase KRK KBNK")
+		fmt.Println("tables are written to", tablebase.DefaultTablebasesDir())
+		return
+	}
+	if len(args) == 0 {
+		args = defaultMaterials
+	}
+
+	dir := tablebase.DefaultTablebasesDir()
+
+	// Reuse the tables that were already generated
+	tbs, err := tablebase.LoadTablebases(dir)
+	if !IsNil(err) {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	tbs.Logger = &DefaultLogger
+
+	for _, arg := range args {
+		material, err := tablebase.ParseMaterial(arg)
+		if IsNil(err) {
+			err = tbs.Generate(material)
+		}
+		if !IsNil(err) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+	}
+
+	err = tbs.Save(dir)
+	if !IsNil(err) {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("wrote", tbs.Materials(), "to", dir)
+}
@@ -8,10 +8,46 @@ import (
 
 	"github.com/cricklet/chessgo/internal/chessgo"
 	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
+	"github.com/cricklet/chessgo/internal/tablebase"
 	"github.com/cricklet/chessgo/internal/uci"
 	"github.com/pkg/profile"
 )
 
+// searchFlags are the arguments that can be passed to the binary to disable
+// parts of the search or enable experimental ones. `cmd/elo` runs
+// `<binary> options` and plays each printed line of arguments against the others.
+var searchFlags = []Pair[string, func(*search.SearchOptions)]{
+	{First: "no-null-move", Second: func(o *search.SearchOptions) { o.WithoutNullMovePruning = true }},
+	{First: "no-lmr", Second: func(o *search.SearchOptions) { o.WithoutLateMoveReductions = true }},
+	{First: "no-pvs", Second: func(o *search.SearchOptions) { o.WithoutPrincipalVariationSearch = true }},
+	{First: "no-aspiration", Second: func(o *search.SearchOptions) { o.WithoutAspirationWindows = true }},
+	{First: "no-see", Second: func(o *search.SearchOptions) { o.WithoutSEEPruning = true }},
+	{First: "no-mate-distance", Second: func(o *search.SearchOptions) { o.WithoutMateDistancePruning = true }},
+	{First: "no-check-ext", Second: func(o *search.SearchOptions) { o.WithoutCheckExtensions = true }},
+	{First: "recapture-ext", Second: func(o *search.SearchOptions) { o.RecaptureExtensions = true }},
+	{First: "no-passed-pawn-ext", Second: func(o *search.SearchOptions) { o.WithoutPassedPawnExtensions = true }},
+	{First: "no-singular-ext", Second: func(o *search.SearchOptions) { o.WithoutSingularExtensions = true }},
+	{First: "no-delta", Second: func(o *search.SearchOptions) { o.WithoutDeltaPruning = true }},
+	{First: "no-futility", Second: func(o *search.SearchOptions) { o.WithoutFutilityPruning = true }},
+	{First: "no-rfp", Second: func(o *search.SearchOptions) { o.WithoutReverseFutilityPruning = true }},
+	{First: "no-razoring", Second: func(o *search.SearchOptions) { o.WithoutRazoring = true }},
+	{First: "no-qs-checks", Second: func(o *search.SearchOptions) { o.WithoutQuiescenceChecks = true }},
+	{First: "no-incremental-eval", Second: func(o *search.SearchOptions) { o.WithoutIncrementalEvaluation = true }},
+	{First: "no-staged-movegen", Second: func(o *search.SearchOptions) { o.WithoutStagedMoveGeneration = true }},
+	{First: "history", Second: func(o *search.SearchOptions) { o.CreateMoveSorter = Some(search.CreateHistoryMoveSorter) }},
+	{First: "no-tablebase", Second: func(o *search.SearchOptions) { o.Tablebase = Empty[search.Tablebase]() }},
+	{First: "skill-0", Second: func(o *search.SearchOptions) { o.Skill = Some(search.Skill{Level: 0}) }},
+	{First: "skill-5", Second: func(o *search.SearchOptions) { o.Skill = Some(search.Skill{Level: 5}) }},
+	{First: "skill-10", Second: func(o *search.SearchOptions) { o.Skill = Some(search.Skill{Level: 10}) }},
+	{First: "skill-15", Second: func(o *search.SearchOptions) { o.Skill = Some(search.Skill{Level: 15}) }},
+}
+
+// matchFlags don't change the search, they tell cmd/elo how to play the binary
+var matchFlags = []string{
+	"ponder",
+}
+
 func main() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -27,28 +63,78 @@ func main() {
 		defer p.Stop()
 	}
 
+	if Contains(args, "options") {
+		for _, flag := range searchFlags {
+			fmt.Println(flag.First)
+		}
+		for _, flag := range matchFlags {
+			fmt.Println(flag)
+		}
+		return
+	}
+
+	logger := FuncLogger(
+		func(s string) {
+			fmt.Print(s)
+		})
+
+	searchOptions := search.SearchOptions{
+		MaxDepth: Some(10),
+		Logger:   Some[Logger](logger),
+	}
+
+	// Generated by cmd/tablebase
+	tablebases, err := tablebase.LoadTablebases(tablebase.DefaultTablebasesDir())
+	if !IsNil(err) {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	} else if len(tablebases.Materials()) > 0 {
+		searchOptions.Tablebase = Some[search.Tablebase](tablebases)
+	}
+
+	for _, flag := range searchFlags {
+		if Contains(args, flag.First) {
+			flag.Second(&searchOptions)
+		}
+	}
+
 	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{
-		Logger: Some(FuncLogger(
-			func(s string) {
-				fmt.Print(s)
-			})),
+		Logger:            Some[Logger](logger),
+		SearchConstructor: Some(search.SearchHelperFromOptions(searchOptions)),
 	})
 
 	uciRunner := uci.NewUciRunner(runner)
+	uciRunner.SetInfoOutput(func(line string) {
+		fmt.Println(line)
+	})
 
-	scanner := bufio.NewScanner(os.Stdin)
+	// Read on another goroutine so that "stop" arrives while searching
+	inputs := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			inputs <- scanner.Text()
+		}
+		close(inputs)
+	}()
 
-	done := false
-	for !done && scanner.Scan() {
-		input := scanner.Text()
-		if input == "quit" {
-			break
+	for {
+		var result []string
+		var err Error
+
+		select {
+		case input, ok := <-inputs:
+			if !ok || input == "quit" {
+				return
+			}
+			result, err = uciRunner.HandleInput(input)
+		case <-uciRunner.SearchDone():
+			result, err = uciRunner.FinishSearch()
 		}
-		result, err := uciRunner.HandleInput(input)
+
 		if !IsNil(err) {
 			fmt.Fprintln(os.Stderr, "error:", err)
 			time.Sleep(200 * time.Millisecond)
-			break
+			return
 		}
 		for _, v := range result {
 			fmt.Println(v)
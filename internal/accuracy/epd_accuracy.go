@@ -326,7 +326,8 @@ func CalculateScoreForEveryMove(
 			return scores, Errorf("no score found for %v", move.String())
 		}
 
-		score := -enemyScore.Value()
+		// Mates are counted from the position before `move`
+		score := ScoreFromEnemyScore(enemyScore.Value())
 
 		logger.Printf("(%v / %v) score for %v is %v\n", i+1, len(moves), move.String(), ScoreString(score))
 		scores[move.String()] = score
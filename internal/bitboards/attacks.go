@@ -0,0 +1,56 @@
+package bitboards
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// PawnAttackMasks[player][index] are the squares attacked by a pawn belonging
+// to `player` standing on `index`.
+var PawnAttackMasks [2][64]Bitboard = func() [2][64]Bitboard {
+	result := [2][64]Bitboard{}
+
+	for _, player := range []Player{White, Black} {
+		for i := 0; i < 64; i++ {
+			pieceBoard := SingleBitboard(i)
+			for _, offset := range PawnCaptureOffsets[player] {
+				potential := pieceBoard & PremoveMaskFromOffset(offset)
+				result[player][i] |= RotateTowardsIndex64(potential, offset)
+			}
+		}
+	}
+	return result
+}()
+
+// Attacks returns the squares a slider on `index` attacks given the occupancy
+// of the board. The result includes the first blocker in each direction.
+func (t *MagicMoveTable) Attacks(index int, occupied Bitboard) Bitboard {
+	blockerBoard := t.BlockerMasks[index] & occupied
+	magicValues := t.Magics[index]
+	magicIndex := MagicIndex(magicValues.Magic, blockerBoard, magicValues.BitsInMagicIndex)
+	return t.Moves[index][magicIndex]
+}
+
+// AttackersToSquare returns the pieces of both players that attack `index`.
+//
+// Sliders are looked up using `occupied` rather than b.Occupied. Removing
+// pieces from `occupied` reveals the x-ray attackers standing behind them,
+// which is how exchanges on a single square are resolved (see SEE in the
+// search package). Pieces missing from `occupied` are not returned.
+func AttackersToSquare(b *Bitboards, index int, occupied Bitboard) Bitboard {
+	white := &b.Players[White].Pieces
+	black := &b.Players[Black].Pieces
+
+	diagonal := white[Bishop] | white[Queen] | black[Bishop] | black[Queen]
+	orthogonal := white[Rook] | white[Queen] | black[Rook] | black[Queen]
+
+	attackers := BishopMagicTable.Attacks(index, occupied) & diagonal
+	attackers |= RookMagicTable.Attacks(index, occupied) & orthogonal
+	attackers |= KnightAttackMasks[index] & (white[Knight] | black[Knight])
+	attackers |= KingAttackMasks[index] & (white[King] | black[King])
+
+	// A white pawn attacks `index` from the squares a black pawn on `index` would attack
+	attackers |= PawnAttackMasks[Black][index] & white[Pawn]
+	attackers |= PawnAttackMasks[White][index] & black[Pawn]
+
+	return attackers & occupied
+}
@@ -0,0 +1,173 @@
+package book
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// Entry is one record of a Polyglot book. Entries are sorted by key and then
+// by weight, best first.
+type Entry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+const _entrySize = 16
+
+// Book is an opening book in Polyglot's .bin format
+type Book struct {
+	entries []Entry
+}
+
+// BookMove is a legal move from the book
+type BookMove struct {
+	Move   Move
+	Weight int
+}
+
+func NewBook(entries []Entry) *Book {
+	b := &Book{entries: append([]Entry{}, entries...)}
+	sort.Slice(b.entries, func(i, j int) bool {
+		x, y := b.entries[i], b.entries[j]
+		if x.Key != y.Key {
+			return x.Key < y.Key
+		}
+		if x.Weight != y.Weight {
+			return x.Weight > y.Weight
+		}
+		return x.Move < y.Move
+	})
+	return b
+}
+
+func (b *Book) Entries() []Entry {
+	return b.entries
+}
+
+// EntriesForKey returns the entries for a position, see Key
+func (b *Book) EntriesForKey(key uint64) []Entry {
+	start := sort.Search(len(b.entries), func(i int) bool {
+		return b.entries[i].Key >= key
+	})
+	end := start
+	for end < len(b.entries) && b.entries[end].Key == key {
+		end++
+	}
+	return b.entries[start:end]
+}
+
+// Moves returns the legal book moves for the position, best first
+func (b *Book) Moves(g *GameState) ([]BookMove, Error) {
+	result := []BookMove{}
+	for _, entry := range b.EntriesForKey(Key(g)) {
+		move, err := DecodeMove(g, entry.Move)
+		if !IsNil(err) {
+			return nil, err
+		}
+		if move.HasValue() {
+			result = append(result, BookMove{Move: move.Value(), Weight: int(entry.Weight)})
+		}
+	}
+	return result, NilError
+}
+
+// PickMove chooses a move at random, in proportion to its weight. Moves with
+// no weight are never played.
+func PickMove(moves []BookMove, r *rand.Rand) Optional[Move] {
+	total := ReduceSlice(moves, 0, func(sum int, m BookMove) int { return sum + m.Weight })
+	if total == 0 {
+		return Empty[Move]()
+	}
+
+	choice := r.Intn(total)
+	for _, m := range moves {
+		if choice < m.Weight {
+			return Some(m.Move)
+		}
+		choice -= m.Weight
+	}
+	return Empty[Move]()
+}
+
+// Write writes the entries in Polyglot's format: big-endian records of the
+// key, move, weight and learn values
+func (b *Book) Write(w io.Writer) Error {
+	buffered := bufio.NewWriter(w)
+
+	record := [_entrySize]byte{}
+	for _, entry := range b.entries {
+		binary.BigEndian.PutUint64(record[0:8], entry.Key)
+		binary.BigEndian.PutUint16(record[8:10], entry.Move)
+		binary.BigEndian.PutUint16(record[10:12], entry.Weight)
+		binary.BigEndian.PutUint32(record[12:16], entry.Learn)
+
+		_, err := buffered.Write(record[:])
+		if err != nil {
+			return Wrap(err)
+		}
+	}
+
+	return Wrap(buffered.Flush())
+}
+
+func ReadBook(r io.Reader) (*Book, Error) {
+	reader := bufio.NewReader(r)
+
+	entries := []Entry{}
+	record := [_entrySize]byte{}
+	for {
+		_, err := io.ReadFull(reader, record[:])
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, Errorf("book ends with a partial entry")
+		}
+		if err != nil {
+			return nil, Wrap(err)
+		}
+
+		entries = append(entries, Entry{
+			Key:    binary.BigEndian.Uint64(record[0:8]),
+			Move:   binary.BigEndian.Uint16(record[8:10]),
+			Weight: binary.BigEndian.Uint16(record[10:12]),
+			Learn:  binary.BigEndian.Uint32(record[12:16]),
+		})
+	}
+
+	return NewBook(entries), NilError
+}
+
+func LoadBook(path string) (*Book, Error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, Wrap(err)
+	}
+	defer file.Close()
+
+	b, readErr := ReadBook(file)
+	if !IsNil(readErr) {
+		return nil, Errorf("couldn't read %v: %w", path, readErr)
+	}
+	return b, NilError
+}
+
+func (b *Book) Save(path string) Error {
+	file, err := os.Create(path)
+	if err != nil {
+		return Wrap(err)
+	}
+
+	writeErr := b.Write(file)
+	closeErr := Wrap(file.Close())
+	return Join(writeErr, closeErr)
+}
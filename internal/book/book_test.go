@@ -0,0 +1,207 @@
+package book
+
+import (
+	"bytes"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func gameFromFen(t *testing.T, fen string) *game.GameState {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+	return g
+}
+
+func TestPolyglotKeys(t *testing.T) {
+	// The examples from http://hgm.nubati.net/book_format.html
+	for fen, expected := range map[string]uint64{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1":      0x463b96181691fc9c,
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1":   0x823c9b50fd114196,
+		"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2": 0x0756b94461c50fb0,
+		"rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2":   0x662fafb965db29d4,
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3": 0x22a48b5a8e47ff78,
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3":    0x652a607ca3f242c1,
+		"rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4":     0x00fdd303c946bdd9,
+		"rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3": 0x3c8123ea7b067637,
+		"rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4":  0x5c3f9b829b279560,
+	} {
+		assert.Equal(t, expected, Key(gameFromFen(t, fen)), fen)
+	}
+}
+
+func TestEncodeMove(t *testing.T) {
+	g := gameFromFen(t, "r3k2r/1P6/8/8/8/8/8/R3K2R w KQkq - 0 1")
+
+	for input, expected := range map[string]string{
+		"e1g1":  "e1h1",
+		"e1c1":  "e1a1",
+		"b7a8q": "b7a8",
+		"a1a8":  "a1a8",
+	} {
+		encoded := EncodeMove(g.MoveFromString(input))
+		assert.Equal(t, expected, StringFromBoardIndex(int(encoded>>6&63))+StringFromBoardIndex(int(encoded&63)))
+
+		decoded, err := DecodeMove(g, encoded)
+		assert.True(t, IsNil(err), err)
+		assert.Equal(t, input, decoded.Value().String())
+	}
+
+	promotion := EncodeMove(g.MoveFromString("b7b8n"))
+	assert.Equal(t, uint16(1), promotion>>12)
+	decoded, err := DecodeMove(g, promotion)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, "b7b8n", decoded.Value().String())
+
+	// Not legal in this position
+	decoded, err = DecodeMove(g, EncodeMove(g.MoveFromString("e2e4")))
+	assert.True(t, IsNil(err), err)
+	assert.True(t, decoded.IsEmpty())
+}
+
+func TestMoveFromSan(t *testing.T) {
+	g := gameFromFen(t, "r3k2r/1P1n1n2/8/3pP3/8/5N1N/8/R3K2R w KQkq d6 0 1")
+
+	for san, expected := range map[string]string{
+		"O-O":     "e1g1",
+		"O-O-O+":  "e1c1",
+		"exd6":    "e5d6",
+		"Nf3g5":   "f3g5",
+		"Nhg5":    "h3g5",
+		"bxa8=Q+": "b7a8q",
+		"b8=N!":   "b7b8n",
+		"Rxa8":    "a1a8",
+	} {
+		move, err := MoveFromSan(g, san)
+		assert.True(t, IsNil(err), err)
+		assert.Equal(t, expected, move.String(), san)
+	}
+
+	for _, san := range []string{"Ng5", "d6", "Qd1", "b8", "O-O-O-O"} {
+		_, err := MoveFromSan(g, san)
+		assert.False(t, IsNil(err), san)
+	}
+}
+
+const _pgn = `[Event "First"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+
+1. e4 e5 {a comment
+over two lines} 2. Nf3 (2. f4 exf4) Nc6 $1 3. Bb5!? a6 ; the Ruy Lopez
+4. Ba4 1-0
+
+[Event "Second"]
+[Result "1/2-1/2"]
+
+1.e4 c5 2.Nf3 1/2-1/2
+
+[Event "Third"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 *
+`
+
+func TestReadPgn(t *testing.T) {
+	games, err := ReadPgn(strings.NewReader(_pgn))
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, 3, len(games))
+
+	assert.Equal(t, "First", games[0].Tags["Event"])
+	assert.Equal(t, []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4"}, games[0].Moves)
+	assert.Equal(t, "1-0", games[0].Result)
+
+	assert.Equal(t, []string{"e4", "c5", "Nf3"}, games[1].Moves)
+	assert.Equal(t, "1/2-1/2", games[1].Result)
+
+	assert.Equal(t, "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", games[2].StartFen())
+	assert.Equal(t, "*", games[2].Result)
+}
+
+func bookMoves(t *testing.T, b *Book, fen string) map[string]int {
+	moves, err := b.Moves(gameFromFen(t, fen))
+	assert.True(t, IsNil(err), err)
+
+	result := map[string]int{}
+	for _, m := range moves {
+		result[m.Move.String()] = m.Weight
+	}
+	return result
+}
+
+func TestBuildBook(t *testing.T) {
+	games, err := ReadPgn(strings.NewReader(_pgn))
+	assert.True(t, IsNil(err), err)
+
+	b, err := Build(games, BuildOptions{})
+	assert.True(t, IsNil(err), err)
+
+	// e4 won once and drew once, the unfinished game doesn't count
+	start := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	assert.Equal(t, map[string]int{"e2e4": 3}, bookMoves(t, b, start))
+	// Moves that only lost or drew for the losing side are left out
+	afterE4 := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	assert.Equal(t, map[string]int{"c7c5": 1}, bookMoves(t, b, afterE4))
+	assert.Equal(t, 0, len(bookMoves(t, b, "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")))
+
+	// The weight filter
+	b, err = Build(games, BuildOptions{MinWeight: Some(3)})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, 1, len(b.Entries()))
+
+	// The depth filter
+	b, err = Build(games, BuildOptions{MaxPlies: Some(2), MinWeight: Some(0)})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, map[string]int{"e7e5": 0, "c7c5": 1}, bookMoves(t, b, afterE4))
+	assert.Equal(t, 5, len(b.Entries()))
+}
+
+func TestWriteAndReadBook(t *testing.T) {
+	games, err := ReadPgn(strings.NewReader(_pgn))
+	assert.True(t, IsNil(err), err)
+	b, err := Build(games, BuildOptions{})
+	assert.True(t, IsNil(err), err)
+
+	buffer := bytes.Buffer{}
+	assert.True(t, IsNil(b.Write(&buffer)))
+	assert.Equal(t, 16*len(b.Entries()), buffer.Len())
+
+	read, err := ReadBook(bytes.NewReader(buffer.Bytes()))
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, b.Entries(), read.Entries())
+
+	_, err = ReadBook(bytes.NewReader(buffer.Bytes()[:20]))
+	assert.False(t, IsNil(err))
+
+	path := filepath.Join(t.TempDir(), "book.bin")
+	assert.True(t, IsNil(b.Save(path)))
+	loaded, err := LoadBook(path)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, b.Entries(), loaded.Entries())
+}
+
+func TestPickMove(t *testing.T) {
+	g := gameFromFen(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	moves := []BookMove{
+		{Move: g.MoveFromString("e2e4"), Weight: 3},
+		{Move: g.MoveFromString("d2d4"), Weight: 1},
+		{Move: g.MoveFromString("g2g4"), Weight: 0},
+	}
+
+	counts := map[string]int{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		counts[PickMove(moves, r).Value().String()]++
+	}
+	assert.InDelta(t, 750, counts["e2e4"], 60)
+	assert.InDelta(t, 250, counts["d2d4"], 60)
+	assert.Equal(t, 0, counts["g2g4"])
+
+	assert.True(t, PickMove(moves[2:], r).IsEmpty())
+}
@@ -0,0 +1,102 @@
+package book
+
+import (
+	"math"
+
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+type BuildOptions struct {
+	// Only the first plies of each game are added, defaults to 24
+	MaxPlies Optional[int]
+
+	// Moves are weighted by their results for the player that made them: 2
+	// for a win and 1 for a draw. Moves with less weight are left out,
+	// defaults to 1 so that moves which only lost aren't played.
+	MinWeight Optional[int]
+}
+
+const _defaultMaxPlies = 24
+
+type bookKey struct {
+	key  uint64
+	move uint16
+}
+
+// Build creates a book from the moves played in `games`
+func Build(games []PgnGame, options BuildOptions) (*Book, Error) {
+	maxPlies := _defaultMaxPlies
+	if options.MaxPlies.HasValue() {
+		maxPlies = options.MaxPlies.Value()
+	}
+	minWeight := 1
+	if options.MinWeight.HasValue() {
+		minWeight = options.MinWeight.Value()
+	}
+
+	weights := map[bookKey]int{}
+	for i, game := range games {
+		err := addGame(weights, game, maxPlies)
+		if !IsNil(err) {
+			return nil, Errorf("game %v: %w", i+1, err)
+		}
+	}
+
+	// Weights are scaled to fit in an entry, separately for each position
+	maxWeights := map[uint64]int{}
+	for k, weight := range weights {
+		maxWeights[k.key] = MaxInt(maxWeights[k.key], weight)
+	}
+
+	entries := []Entry{}
+	for k, weight := range weights {
+		if weight < minWeight {
+			continue
+		}
+		if maxWeights[k.key] > math.MaxUint16 {
+			weight = MaxInt(1, weight*math.MaxUint16/maxWeights[k.key])
+		}
+		entries = append(entries, Entry{Key: k.key, Move: k.move, Weight: uint16(weight)})
+	}
+
+	return NewBook(entries), NilError
+}
+
+func addGame(weights map[bookKey]int, game PgnGame, maxPlies int) Error {
+	g, err := GamestateFromFenString(game.StartFen())
+	if !IsNil(err) {
+		return err
+	}
+
+	for ply, san := range game.Moves {
+		if ply >= maxPlies {
+			break
+		}
+
+		move, err := MoveFromSan(g, san)
+		if !IsNil(err) {
+			return err
+		}
+
+		k := bookKey{key: Key(g), move: EncodeMove(move)}
+		weights[k] += resultWeight(game.Result, g.Player)
+
+		err = g.PerformMove(move, &BoardUpdate{})
+		if !IsNil(err) {
+			return err
+		}
+	}
+
+	return NilError
+}
+
+func resultWeight(result string, player Player) int {
+	switch {
+	case result == "1/2-1/2":
+		return 1
+	case result == "1-0" && player == White, result == "0-1" && player == Black:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,256 @@
+package book
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// PgnGame is a game from a PGN collection. Moves are in standard algebraic
+// notation, eg "Nf3" or "exd5", see MoveFromSan.
+type PgnGame struct {
+	Tags   map[string]string
+	Moves  []string
+	Result string
+}
+
+const _startFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// StartFen is the position the game starts from, set by the FEN tag
+func (p PgnGame) StartFen() string {
+	if fen, ok := p.Tags["FEN"]; ok {
+		return fen
+	}
+	return _startFen
+}
+
+var _pgnResults = []string{"1-0", "0-1", "1/2-1/2", "*"}
+
+var _tagRegex = regexp.MustCompile(`^\[(\w+)\s+"(.*)"\]$`)
+var _moveNumberRegex = regexp.MustCompile(`^\d+\.+`)
+
+// ReadPgn reads every game in a PGN collection. Comments, variations and
+// annotations are skipped.
+func ReadPgn(r io.Reader) ([]PgnGame, Error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, Wrap(err)
+	}
+
+	games := []PgnGame{}
+	current := PgnGame{Tags: map[string]string{}}
+	inMoves := false
+
+	finish := func(result string) {
+		current.Result = result
+		games = append(games, current)
+		current = PgnGame{Tags: map[string]string{}}
+		inMoves = false
+	}
+
+	tokens, tokenErr := pgnTokens(string(input))
+	if !IsNil(tokenErr) {
+		return nil, tokenErr
+	}
+
+	for _, token := range tokens {
+		if match := _tagRegex.FindStringSubmatch(token); match != nil {
+			// A game without a result ends at the next game's tags
+			if inMoves {
+				finish("*")
+			}
+			current.Tags[match[1]] = strings.ReplaceAll(match[2], `\"`, `"`)
+			continue
+		}
+
+		if Contains(_pgnResults, token) {
+			finish(token)
+			continue
+		}
+
+		token = _moveNumberRegex.ReplaceAllString(token, "")
+		token = strings.TrimRight(token, "!?")
+		if token == "" || strings.HasPrefix(token, "$") {
+			continue
+		}
+
+		current.Moves = append(current.Moves, token)
+		inMoves = true
+	}
+
+	if inMoves {
+		finish("*")
+	}
+
+	return games, NilError
+}
+
+// pgnTokens splits the movetext into tags, moves and results
+func pgnTokens(input string) ([]string, Error) {
+	tokens := []string{}
+	variations := 0
+
+	lines := strings.Split(input, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		// Lines starting with % are escaped
+		if strings.HasPrefix(line, "%") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && variations == 0 {
+			tokens = append(tokens, line)
+			continue
+		}
+
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case c == '{':
+				// Comments can span lines
+				end := strings.IndexByte(line[j:], '}')
+				for end == -1 && i+1 < len(lines) {
+					i++
+					line = line[j:] + " " + lines[i]
+					j = 0
+					end = strings.IndexByte(line, '}')
+				}
+				if end == -1 {
+					return nil, Errorf("unterminated comment")
+				}
+				j += end
+			case c == ';':
+				j = len(line)
+			case c == '(':
+				variations++
+			case c == ')':
+				variations--
+			case unicode.IsSpace(rune(c)):
+			default:
+				end := j
+				for end < len(line) && !strings.ContainsRune(" \t\r{};()", rune(line[end])) {
+					end++
+				}
+				if variations == 0 {
+					tokens = append(tokens, line[j:end])
+				}
+				j = end - 1
+			}
+		}
+	}
+
+	return tokens, NilError
+}
+
+var _sanPieces = map[byte]PieceType{'K': King, 'Q': Queen, 'R': Rook, 'B': Bishop, 'N': Knight}
+
+// MoveFromSan finds the legal move for standard algebraic notation, eg "Nbd7",
+// "exd8=Q+" or "O-O"
+func MoveFromSan(g *GameState, san string) (Move, Error) {
+	s := strings.TrimRight(san, "+#!?")
+
+	legalMoves, err := legalMoves(g)
+	if !IsNil(err) {
+		return Move{}, err
+	}
+
+	matches := []Move{}
+	if castling := strings.ReplaceAll(s, "0", "O"); castling == "O-O" || castling == "O-O-O" {
+		for _, move := range legalMoves {
+			kingside := move.EndIndex > move.StartIndex
+			if move.MoveType == CastlingMove && kingside == (castling == "O-O") {
+				matches = append(matches, move)
+			}
+		}
+	} else {
+		parsed, err := parseSan(s)
+		if !IsNil(err) {
+			return Move{}, Errorf("couldn't parse '%v': %w", san, err)
+		}
+		for _, move := range legalMoves {
+			if parsed.matches(g, move) {
+				matches = append(matches, move)
+			}
+		}
+	}
+
+	if len(matches) != 1 {
+		return Move{}, Errorf("'%v' matches %v legal moves in %v", san, len(matches), FenStringForGame(g))
+	}
+	return matches[0], NilError
+}
+
+type sanMove struct {
+	piece     PieceType
+	end       int
+	promotion Optional[PieceType]
+
+	// Disambiguates the start square, eg the b in Nbd7
+	file Optional[File]
+	rank Optional[Rank]
+}
+
+func parseSan(s string) (sanMove, Error) {
+	result := sanMove{piece: Pawn}
+
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		if i+2 != len(s) || s[i+1] == 'K' {
+			return result, Errorf("invalid promotion")
+		}
+		piece, ok := _sanPieces[s[i+1]]
+		if !ok {
+			return result, Errorf("invalid promotion")
+		}
+		result.promotion = Some(piece)
+		s = s[:i]
+	}
+
+	if len(s) > 0 {
+		if piece, ok := _sanPieces[s[0]]; ok {
+			result.piece = piece
+			s = s[1:]
+		}
+	}
+
+	if len(s) < 2 {
+		return result, Errorf("missing the end square")
+	}
+	end, err := FileRankFromString(s[len(s)-2:])
+	if !IsNil(err) {
+		return result, err
+	}
+	result.end = IndexFromFileRank(end)
+
+	// Pawns only change files when capturing, eg exd5
+	if result.piece == Pawn && !strings.Contains(s, "x") {
+		result.file = Some(end.File)
+	}
+
+	for _, c := range []byte(strings.ReplaceAll(s[:len(s)-2], "x", "")) {
+		if IsFile(c) {
+			file, _ := FileFromChar(c)
+			result.file = Some(file)
+		} else if IsRank(c) {
+			rank, _ := RankFromChar(c)
+			result.rank = Some(rank)
+		} else {
+			return result, Errorf("unexpected '%c'", c)
+		}
+	}
+
+	return result, NilError
+}
+
+func (s sanMove) matches(g *GameState, move Move) bool {
+	start := FileRankFromIndex(move.StartIndex)
+	return move.EndIndex == s.end &&
+		move.MoveType != CastlingMove &&
+		g.Board[move.StartIndex].PieceType() == s.piece &&
+		move.PromotionPiece == s.promotion &&
+		(s.file.IsEmpty() || s.file.Value() == start.File) &&
+		(s.rank.IsEmpty() || s.rank.Value() == start.Rank)
+}
@@ -0,0 +1,134 @@
+package book
+
+import (
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
+)
+
+// Offsets into _random64, see http://hgm.nubati.net/book_format.html
+const (
+	_castlingOffset  = 768
+	_enPassantOffset = 772
+	_turnOffset      = 780
+)
+
+// _pieceKinds orders the pieces the way Polyglot does: black pawn, white pawn,
+// black knight, white knight, etc
+var _pieceKinds = func() [13]int {
+	result := [13]int{}
+	for i, pieceType := range []PieceType{Pawn, Knight, Bishop, Rook, Queen, King} {
+		result[PieceForPlayer[Black][pieceType]] = 2 * i
+		result[PieceForPlayer[White][pieceType]] = 2*i + 1
+	}
+	return result
+}()
+
+// Key is the Polyglot hash of the position. It isn't the same as
+// GameState.ZobristHash because books need Polyglot's keys.
+func Key(g *GameState) uint64 {
+	key := uint64(0)
+	for index, piece := range g.Board {
+		if piece != XX {
+			key ^= _random64[64*_pieceKinds[piece]+index]
+		}
+	}
+
+	for player := White; player <= Black; player++ {
+		for _, side := range AllCastlingSides {
+			if g.PlayerAndCastlingSideAllowed[player][side] {
+				key ^= _random64[_castlingOffset+2*int(player)+int(side)]
+			}
+		}
+	}
+
+	// Polyglot only hashes the en-passant file if a pawn can capture there
+	if g.EnPassantTarget.HasValue() && canCaptureEnPassant(g) {
+		key ^= _random64[_enPassantOffset+int(g.EnPassantTarget.Value().File)]
+	}
+
+	if g.Player == White {
+		key ^= _random64[_turnOffset]
+	}
+
+	return key
+}
+
+func canCaptureEnPassant(g *GameState) bool {
+	target := g.EnPassantTarget.Value()
+	rank := int(target.Rank) - 1
+	if g.Player == Black {
+		rank = int(target.Rank) + 1
+	}
+
+	pawn := PieceForPlayer[g.Player][Pawn]
+	for _, file := range []int{int(target.File) - 1, int(target.File) + 1} {
+		if file >= 0 && file < 8 && g.Board[rank*8+file] == pawn {
+			return true
+		}
+	}
+	return false
+}
+
+// Polyglot numbers promotions from 1
+var _promotions = [...]uint16{Knight: 1, Bishop: 2, Rook: 3, Queen: 4}
+
+// EncodeMove packs the move the way Polyglot does: the end square in bits
+// 0-5, the start square in bits 6-11 and the promotion in bits 12-14.
+// Castling is written as the king capturing its own rook, eg e1h1.
+func EncodeMove(move Move) uint16 {
+	end := move.EndIndex
+	if move.MoveType == CastlingMove {
+		end = castlingRookIndex(move)
+	}
+
+	result := uint16(end) | uint16(move.StartIndex)<<6
+	if move.PromotionPiece.HasValue() {
+		result |= _promotions[move.PromotionPiece.Value()] << 12
+	}
+	return result
+}
+
+func castlingRookIndex(move Move) int {
+	if move.EndIndex > move.StartIndex {
+		return move.StartIndex + 3
+	}
+	return move.StartIndex - 4
+}
+
+// DecodeMove finds the legal move for a Polyglot move. It's empty if the move
+// isn't legal, eg because of a hash collision.
+func DecodeMove(g *GameState, encoded uint16) (Optional[Move], Error) {
+	moves, err := legalMoves(g)
+	if !IsNil(err) {
+		return Empty[Move](), err
+	}
+
+	for _, move := range moves {
+		if EncodeMove(move) == encoded {
+			return Some(move), NilError
+		}
+	}
+	return Empty[Move](), NilError
+}
+
+// legalMoves includes underpromotions, which the search doesn't generate
+func legalMoves(g *GameState) ([]Move, Error) {
+	moves := []Move{}
+	err := search.GenerateLegalMoves(g, &moves)
+	if !IsNil(err) {
+		return nil, err
+	}
+
+	result := []Move{}
+	for _, move := range moves {
+		result = append(result, move)
+		if move.PromotionPiece.HasValue() {
+			for _, promotion := range []PieceType{Rook, Bishop, Knight} {
+				move.PromotionPiece = Some(promotion)
+				result = append(result, move)
+			}
+		}
+	}
+	return result, NilError
+}
@@ -1,10 +1,13 @@
 package chessgo
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
 	. "github.com/cricklet/chessgo/internal/bitboards"
+	"github.com/cricklet/chessgo/internal/book"
 	. "github.com/cricklet/chessgo/internal/game"
 	. "github.com/cricklet/chessgo/internal/helpers"
 	"github.com/cricklet/chessgo/internal/search"
@@ -16,10 +19,40 @@ type ChessGoRunner struct {
 	g *GameState
 	s *search.SearchHelper
 
+	// Kept from one position to the next, unless SearchConstructor creates
+	// the search
+	table *search.TranspositionTable
+	// The limits of the search from SetupPosition. Searches that set their
+	// own limits only change them until the next search.
+	maxDepth         Optional[int]
+	maxNodes         Optional[int]
+	includeRootMoves []string
+	excludeRootMoves []string
+
 	StartFen string
 	history  []HistoryValue
 
+	// The principal variation from the last search
+	pv []Move
+
+	// The running search, see SearchAsync
+	cancelSearch context.CancelFunc
+	searchDone   chan struct{}
+	searchOutput searchOutput
+
 	options ChessGoOptions
+
+	// Loaded from options.BookFile on the first search that needs it
+	book   Optional[*book.Book]
+	random *rand.Rand
+}
+
+type searchOutput struct {
+	result search.SearchResult
+	err    Error
+
+	// The move was played from the book without searching
+	fromBook bool
 }
 
 var _ Runner = (*ChessGoRunner)(nil)
@@ -27,11 +60,27 @@ var _ Runner = (*ChessGoRunner)(nil)
 type ChessGoOptions struct {
 	Logger            Optional[Logger]
 	SearchConstructor Optional[search.SearchHelperConstructor]
+
+	// Play moves from the Polyglot book at BookFile instead of searching
+	// while the position is in the book
+	OwnBook  bool
+	BookFile Optional[string]
+
+	// Weakens the search to SkillLevel, or to the level for Elo with
+	// LimitStrength, see search.Skill
+	SkillLevel    Optional[int]
+	LimitStrength bool
+	Elo           Optional[int]
+
+	// Called on the searching goroutine after each completed depth, see
+	// search.SearchOptions.OnIteration
+	OnIteration Optional[func(search.SearchResult)]
 }
 
 func NewChessGoRunner(opts ChessGoOptions) ChessGoRunner {
 	r := ChessGoRunner{
 		options: opts,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 	if opts.Logger.HasValue() {
 		r.Logger = opts.Logger.Value()
@@ -47,8 +96,15 @@ type HistoryValue struct {
 }
 
 func (r *ChessGoRunner) Reset() {
+	r.Stop()
+	if r.searchDone != nil {
+		<-r.searchDone
+		r.searchDone = nil
+	}
+
 	r.g = nil
 	r.s = nil
+	r.pv = nil
 	r.StartFen = ""
 	r.history = []HistoryValue{}
 }
@@ -80,6 +136,7 @@ func (r *ChessGoRunner) Rewind(num int) Error {
 }
 
 func (r *ChessGoRunner) PerformMove(move Move) Error {
+	r.pv = nil
 	r.history = append(r.history, HistoryValue{})
 
 	h := r.LastHistory()
@@ -144,12 +201,20 @@ func (r *ChessGoRunner) SetupPosition(position Position) Error {
 	if r.options.SearchConstructor.HasValue() {
 		_, r.s = r.options.SearchConstructor.Value()(r.g)
 	} else {
+		if r.table == nil {
+			r.table = search.NewTranspositionTable(search.DefaultTranspositionTableSizeInBytes)
+		}
 		_, r.s = search.NewSearchHelper(r.g, search.SearchOptions{
-			MaxDepth: Some(10),
-			Logger:   Some(r.Logger),
+			MaxDepth:           Some(10),
+			Logger:             Some(r.Logger),
+			TranspositionTable: Some(r.table),
 		})
 	}
 
+	r.maxDepth = r.s.MaxDepth
+	r.maxNodes = r.s.MaxNodes
+	r.includeRootMoves = r.s.IncludeRootMoves
+	r.excludeRootMoves = r.s.ExcludeRootMoves
 	r.StartFen = position.Fen
 
 	for _, m := range position.Moves {
@@ -227,35 +292,309 @@ func (r *ChessGoRunner) Board() BoardArray {
 	return r.g.Board
 }
 
-func (r *ChessGoRunner) Search(searchParams SearchParams) (Optional[string], Optional[int], int, Error) {
-	var err Error
-
-	if searchParams.Duration.HasValue() {
-		r.s.OutOfTime = false
-		go func() {
-			time.Sleep(searchParams.Duration.Value())
-			r.s.OutOfTime = true
-		}()
+// _infiniteSearchDepth is deeper than a search can get before it's stopped
+const _infiniteSearchDepth = 100
+
+func (r *ChessGoRunner) prepareSearch(searchParams SearchParams) Error {
+	if r.s == nil {
+		return Errorf("position not setup")
+	}
+
+	r.s.TimeManager = nil
+	r.s.MaxDepth = r.maxDepth
+	r.s.MaxNodes = r.maxNodes
+	r.s.IncludeRootMoves = r.includeRootMoves
+	r.s.ExcludeRootMoves = r.excludeRootMoves
+
+	if searchParams.Nodes.HasValue() {
+		r.s.MaxNodes = searchParams.Nodes
+	}
+	if len(searchParams.SearchMoves) > 0 {
+		r.s.IncludeRootMoves = searchParams.SearchMoves
+	}
+	if len(searchParams.ExcludeMoves) > 0 {
+		r.s.ExcludeRootMoves = searchParams.ExcludeMoves
+	}
+
+	// Otherwise the search keeps the Skill from its constructor
+	if r.options.LimitStrength || r.options.SkillLevel.HasValue() {
+		r.s.Skill = r.skill()
+	}
+	if r.options.OnIteration.HasValue() {
+		r.s.OnIteration = r.options.OnIteration
+	}
+
+	if searchParams.Infinite {
+		// Runs until it's stopped
+		r.s.SetMaxDepth(_infiniteSearchDepth)
+	} else if searchParams.Clock.HasValue() {
+		legalMoves := []Move{}
+		err := search.GenerateLegalMoves(r.g, &legalMoves)
+		if !IsNil(err) {
+			return err
+		}
+
+		legalMoves = FilterSlice(legalMoves, r.s.AllowsRootMove)
+		r.s.TimeManager = search.NewTimeManager(searchParams.Clock.Value(), r.g.Player, len(legalMoves))
+	} else if searchParams.Duration.HasValue() {
+		r.s.TimeManager = search.NewMoveTimeManager(searchParams.Duration.Value())
 	} else if searchParams.Depth.HasValue() {
 		r.s.SetMaxDepth(searchParams.Depth.Value())
-	} else {
-		return Empty[string](), Empty[int](), 0, Errorf("no search params")
+	} else if searchParams.Mate.HasValue() {
+		// The last move of a mate in N is N*2 - 1 plies away
+		r.s.SetMaxDepth(searchParams.Mate.Value()*2 - 1)
+	} else if searchParams.Nodes.IsEmpty() {
+		return Errorf("no search params")
 	}
 
+	// When pondering on a duration, it starts counting on PonderHit
+	if searchParams.Ponder && r.s.TimeManager != nil {
+		r.s.TimeManager.StartPondering()
+	}
+
+	return NilError
+}
+
+func (r *ChessGoRunner) Search(searchParams SearchParams) (Optional[string], Optional[int], int, Error) {
+	err := r.SearchAsync(searchParams)
+	if !IsNil(err) {
+		return Empty[string](), Empty[int](), 0, err
+	}
+
+	move, score, depth, _, err := r.Wait()
+	return move, score, depth, err
+}
+
+func (r *ChessGoRunner) searching() bool {
+	if r.searchDone == nil {
+		return false
+	}
+	select {
+	case <-r.searchDone:
+		return false
+	default:
+		return true
+	}
+}
+
+// SearchAsync starts searching on another goroutine. Use Wait for the result
+// and Stop to end the search early. The position must not change until the
+// search is done.
+// With OwnBook, book moves are played without searching.
+func (r *ChessGoRunner) SearchAsync(searchParams SearchParams) Error {
+	if r.searching() {
+		return Errorf("a search is already running")
+	}
+
+	err := r.prepareSearch(searchParams)
+	if !IsNil(err) {
+		return err
+	}
+
+	bookMove, err := r.bookMove()
+	if !IsNil(err) {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	r.cancelSearch = cancel
+	r.searchDone = done
+	r.pv = nil
+
+	if bookMove.HasValue() {
+		r.pv = []Move{bookMove.Value()}
+		r.searchOutput = searchOutput{result: search.SearchResult{PV: r.pv, Status: search.Completed}, fromBook: true}
+		cancel()
+		close(done)
+		return NilError
+	}
+
+	go func() {
+		defer close(done)
+		defer cancel()
+
+		result, err := r.s.SearchMultiPV(ctx)
+
+		r.pv = result.PV
+		r.searchOutput = searchOutput{result: result, err: err}
+	}()
+
+	return NilError
+}
+
+// Done is closed when the search started by SearchAsync is done, it's nil if
+// no search was started
+func (r *ChessGoRunner) Done() <-chan struct{} {
+	return r.searchDone
+}
+
+// Wait blocks until the search started by SearchAsync is done and returns its
+// best move, score and depth. The result says whether the search completed or
+// was stopped early. It's safe to call Wait more than once.
+func (r *ChessGoRunner) Wait() (Optional[string], Optional[int], int, search.SearchStatus, Error) {
+	if r.searchDone == nil {
+		return Empty[string](), Empty[int](), 0, search.Failed, Errorf("no search was started")
+	}
+
+	<-r.searchDone
+	output := r.searchOutput
+	result := output.result
+
+	if !IsNil(output.err) {
+		return Empty[string](), Empty[int](), result.Depth, search.Failed, output.err
+	}
+
+	if output.fromBook {
+		return Some(result.PV[0].String()), Empty[int](), 0, result.Status, NilError
+	}
+	if len(result.PV) > 0 {
+		return Some(result.PV[0].String()), Some(result.Score), result.Depth, result.Status, NilError
+	}
+
+	return Empty[string](), Empty[int](), result.Depth, result.Status, NilError
+}
+
+// SetOwnBook turns playing from the book on or off, see ChessGoOptions.OwnBook
+func (r *ChessGoRunner) SetOwnBook(ownBook bool) {
+	r.options.OwnBook = ownBook
+}
+
+// SetBookFile changes the book, it's loaded on the next search. An empty path
+// removes the book.
+func (r *ChessGoRunner) SetBookFile(path string) {
+	r.options.BookFile = Empty[string]()
+	if path != "" {
+		r.options.BookFile = Some(path)
+	}
+	r.book = Empty[*book.Book]()
+}
+
+// SetSkillLevel weakens the search, up to search.MaxSkillLevel for full strength
+func (r *ChessGoRunner) SetSkillLevel(level int) {
+	r.options.SkillLevel = Some(level)
+}
+
+// SetLimitStrength makes the runner play at the strength set by SetElo instead
+// of the skill level, see search.SkillFromElo
+func (r *ChessGoRunner) SetLimitStrength(limitStrength bool) {
+	r.options.LimitStrength = limitStrength
+}
+
+func (r *ChessGoRunner) SetElo(elo int) {
+	r.options.Elo = Some(elo)
+}
+
+// SetOnIteration reports the progress of later searches, see
+// ChessGoOptions.OnIteration
+func (r *ChessGoRunner) SetOnIteration(onIteration func(search.SearchResult)) {
+	r.options.OnIteration = Some(onIteration)
+}
+
+func (r *ChessGoRunner) skill() Optional[search.Skill] {
+	level := r.options.SkillLevel.ValueOr(search.MaxSkillLevel)
+	if r.options.LimitStrength {
+		level = search.SkillFromElo(r.options.Elo.ValueOr(search.MaxSkillElo)).Level
+	}
+	if level >= search.MaxSkillLevel {
+		return Empty[search.Skill]()
+	}
+	return Some(search.Skill{Level: MaxInt(0, level)})
+}
+
+// bookMove picks a move from the book if there's one for the position that
+// the search is allowed to play
+func (r *ChessGoRunner) bookMove() (Optional[Move], Error) {
+	if !r.options.OwnBook || r.options.BookFile.IsEmpty() {
+		return Empty[Move](), NilError
+	}
+
+	if r.book.IsEmpty() {
+		b, err := book.LoadBook(r.options.BookFile.Value())
+		if !IsNil(err) {
+			return Empty[Move](), err
+		}
+		r.book = Some(b)
+	}
+
+	moves, err := r.book.Value().Moves(r.g)
+	if !IsNil(err) {
+		return Empty[Move](), err
+	}
+
+	moves = FilterSlice(moves, func(m book.BookMove) bool {
+		return r.s.AllowsRootMove(m.Move)
+	})
+	return book.PickMove(moves, r.random), NilError
+}
+
+// PonderHit is called when the opponent plays the move we were pondering on.
+// The running search switches to its time limits.
+func (r *ChessGoRunner) PonderHit() {
+	if r.s != nil && r.s.TimeManager != nil {
+		r.s.TimeManager.PonderHit()
+	}
+}
+
+// Stop interrupts the running search, Wait then returns the best move found so
+// far. It's safe to call while another goroutine is blocked in Wait.
+func (r *ChessGoRunner) Stop() {
+	if r.cancelSearch != nil {
+		r.cancelSearch()
+	}
+}
+
+// PrincipalVariation is the best line found by the last search.
+func (r *ChessGoRunner) PrincipalVariation() []string {
+	return MapSlice(r.pv, func(m Move) string {
+		return m.String()
+	})
+}
+
+// PonderMove is the expected reply to the move from the last search.
+func (r *ChessGoRunner) PonderMove() (Optional[string], Error) {
 	if r.s == nil {
-		return Empty[string](), Empty[int](), 0, Errorf("position not setup")
+		return Empty[string](), NilError
+	}
+
+	move, err := r.s.PonderMove(r.pv)
+	if !IsNil(err) || move.IsEmpty() {
+		return Empty[string](), err
 	}
+	return Some(move.Value().String()), NilError
+}
 
-	moves, score, depth, err := r.s.Search()
+// SearchMultiPV returns the best `numLines` moves and their scores, best first.
+func (r *ChessGoRunner) SearchMultiPV(searchParams SearchParams, numLines int) ([]Pair[string, int], int, Error) {
+	if r.searching() {
+		return nil, 0, Errorf("a search is already running")
+	}
+
+	err := r.prepareSearch(searchParams)
 	if !IsNil(err) {
-		return Empty[string](), Empty[int](), depth, err
+		return nil, 0, err
 	}
 
-	if len(moves) > 0 {
-		return Some(moves[0].String()), Some(score), depth, NilError
+	previous := r.s.MultiPV
+	r.s.MultiPV = Some(numLines)
+	defer func() {
+		r.s.MultiPV = previous
+	}()
+
+	result, err := r.s.SearchMultiPV(context.Background())
+	if !IsNil(err) {
+		return nil, result.Depth, err
+	}
+
+	lines := []Pair[string, int]{}
+	for _, line := range result.Lines {
+		if len(line.Second) > 0 {
+			lines = append(lines, Pair[string, int]{First: line.Second[0].String(), Second: line.First})
+		}
 	}
 
-	return Empty[string](), Empty[int](), depth, NilError
+	return lines, result.Depth, NilError
 }
 
 func (r *ChessGoRunner) PlayerIsInCheck() bool {
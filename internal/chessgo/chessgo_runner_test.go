@@ -2,10 +2,14 @@ package chessgo
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/cricklet/chessgo/internal/book"
 	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
 	"github.com/cricklet/chessgo/internal/stockfish"
 	"github.com/pkg/profile"
 	"github.com/stretchr/testify/assert"
@@ -41,6 +45,187 @@ func TestIndexBug3(t *testing.T) {
 	assert.True(t, move.HasValue())
 }
 
+func TestStopAndWait(t *testing.T) {
+	r := NewChessGoRunner(ChessGoOptions{})
+	err := r.SetupPosition(Position{
+		Fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		Moves: []string{},
+	})
+	assert.True(t, IsNil(err))
+
+	start := time.Now()
+	err = r.SearchAsync(SearchParams{Duration: Some(time.Minute)})
+	assert.True(t, IsNil(err))
+
+	// Only one search can run at a time
+	err = r.SearchAsync(SearchParams{Duration: Some(time.Minute)})
+	assert.False(t, IsNil(err))
+	_, _, err = r.SearchMultiPV(SearchParams{Depth: Some(2)}, 2)
+	assert.False(t, IsNil(err))
+
+	time.Sleep(100 * time.Millisecond)
+	go r.Stop()
+
+	move, _, _, result, err := r.Wait()
+	assert.True(t, IsNil(err))
+	assert.True(t, move.HasValue())
+	assert.Equal(t, search.Interrupted, result)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// Waiting again returns the same move
+	again, _, _, _, err := r.Wait()
+	assert.True(t, IsNil(err))
+	assert.Equal(t, move, again)
+
+	_, _, _, result, err = r.Wait()
+	assert.True(t, IsNil(err))
+	assert.Equal(t, search.Interrupted, result)
+
+	// A search that finishes on its own is completed
+	err = r.SearchAsync(SearchParams{Depth: Some(2)})
+	assert.True(t, IsNil(err))
+
+	_, _, depth, result, err := r.Wait()
+	assert.True(t, IsNil(err))
+	assert.Equal(t, 2, depth)
+	assert.Equal(t, search.Completed, result)
+}
+
+func TestOnIteration(t *testing.T) {
+	depths := []int{}
+	r := NewChessGoRunner(ChessGoOptions{
+		OnIteration: Some(func(result search.SearchResult) {
+			depths = append(depths, result.Depth)
+		}),
+	})
+	err := r.SetupPosition(Position{
+		Fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		Moves: []string{},
+	})
+	assert.True(t, IsNil(err))
+
+	move, _, depth, err := r.Search(SearchParams{Depth: Some(3)})
+	assert.True(t, IsNil(err))
+	assert.True(t, move.HasValue())
+	assert.Equal(t, 3, depth)
+	assert.Equal(t, []int{1, 2, 3}, depths)
+}
+
+func TestSearchParamsKeepTheConstructorsLimits(t *testing.T) {
+	r := NewChessGoRunner(ChessGoOptions{
+		SearchConstructor: Some(search.SearchHelperFromOptions(search.SearchOptions{
+			MaxNodes:         Some(5000),
+			IncludeRootMoves: []string{"e2e4", "d2d4"},
+			ExcludeRootMoves: []string{"d2d4"},
+		})),
+	})
+	err := r.SetupPosition(Position{
+		Fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		Moves: []string{},
+	})
+	assert.True(t, IsNil(err))
+
+	move, _, _, err := r.Search(SearchParams{Depth: Some(3)})
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some("e2e4"), move)
+	assert.Equal(t, Some(5000), r.s.MaxNodes)
+
+	// Limits from the params only apply to their own search
+	move, _, _, err = r.Search(SearchParams{Depth: Some(3), Nodes: Some(100), SearchMoves: []string{"g1f3"}, ExcludeMoves: []string{"e2e4"}})
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some("g1f3"), move)
+	assert.Equal(t, Some(100), r.s.MaxNodes)
+
+	move, _, _, err = r.Search(SearchParams{Depth: Some(3)})
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some("e2e4"), move)
+	assert.Equal(t, Some(5000), r.s.MaxNodes)
+}
+
+func TestTranspositionTableIsKeptBetweenPositions(t *testing.T) {
+	position := Position{
+		Fen:   "r3k2r/1bq1bppp/pp2p3/2p1n3/P3PP2/2PBN3/1P1BQ1PP/R4RK1 b kq - 0 16",
+		Moves: []string{},
+	}
+
+	for _, options := range []ChessGoOptions{
+		{},
+		{SearchConstructor: Some(search.SearchHelperFromOptions(search.SearchOptions{}))},
+	} {
+		nodes := 0
+		options.OnIteration = Some(func(result search.SearchResult) {
+			nodes = result.Nodes
+		})
+		r := NewChessGoRunner(options)
+
+		err := r.SetupPosition(position)
+		assert.True(t, IsNil(err))
+		table := r.s.TranspositionTable
+
+		_, _, _, err = r.Search(SearchParams{Depth: Some(5)})
+		assert.True(t, IsNil(err))
+		firstNodes := nodes
+
+		err = r.SetupPosition(position)
+		assert.True(t, IsNil(err))
+		assert.Same(t, table, r.s.TranspositionTable)
+
+		// The second search finds the first one's results
+		_, _, _, err = r.Search(SearchParams{Depth: Some(5)})
+		assert.True(t, IsNil(err))
+		assert.Less(t, nodes, firstNodes)
+	}
+}
+
+func TestOwnBook(t *testing.T) {
+	games, err := book.ReadPgn(strings.NewReader("1. e4 e5 2. Nf3 1-0\n1. e4 c5 1-0"))
+	assert.True(t, IsNil(err), err)
+	b, err := book.Build(games, book.BuildOptions{})
+	assert.True(t, IsNil(err), err)
+
+	path := filepath.Join(t.TempDir(), "book.bin")
+	assert.True(t, IsNil(b.Save(path)))
+
+	r := NewChessGoRunner(ChessGoOptions{OwnBook: true, BookFile: Some(path)})
+	err = r.SetupPosition(Position{
+		Fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		Moves: []string{"e2e4", "e7e5"},
+	})
+	assert.True(t, IsNil(err))
+
+	// Book moves aren't searched, so they don't have a score
+	move, score, depth, err := r.Search(SearchParams{Depth: Some(4)})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, Some("g1f3"), move)
+	assert.True(t, score.IsEmpty())
+	assert.Equal(t, 0, depth)
+
+	// Excluded moves aren't played from the book either
+	move, score, _, err = r.Search(SearchParams{Depth: Some(2), ExcludeMoves: []string{"g1f3"}})
+	assert.True(t, IsNil(err), err)
+	assert.NotEqual(t, Some("g1f3"), move)
+	assert.True(t, score.HasValue())
+
+	// Out of the book
+	err = r.PerformMoveFromString("g1f3")
+	assert.True(t, IsNil(err))
+	_, score, _, err = r.Search(SearchParams{Depth: Some(2)})
+	assert.True(t, IsNil(err), err)
+	assert.True(t, score.HasValue())
+
+	r.SetOwnBook(false)
+	err = r.Rewind(1)
+	assert.True(t, IsNil(err))
+	_, score, _, err = r.Search(SearchParams{Depth: Some(2)})
+	assert.True(t, IsNil(err), err)
+	assert.True(t, score.HasValue())
+
+	r.SetOwnBook(true)
+	r.SetBookFile(filepath.Join(t.TempDir(), "missing.bin"))
+	_, _, _, err = r.Search(SearchParams{Depth: Some(2)})
+	assert.False(t, IsNil(err))
+}
+
 func TestCastlingBug1(t *testing.T) {
 	fen := "rn1qk2r/ppp3pp/3b1n2/3ppb2/8/2NPBNP1/PPP2PBP/R2QK2R b KQkq - 15 8"
 	moves := []string{
@@ -10,9 +10,12 @@ import (
 	"github.com/cricklet/chessgo/internal/zobrist"
 )
 
+// MoveListener is notified after each move and undo, with the squares that
+// changed. Null moves are passed as an empty Move with an update that doesn't
+// change any squares.
 type MoveListener interface {
-	AfterMove(move Move)
-	AfterUndo()
+	AfterMove(move Move, update *BoardUpdate)
+	AfterUndo(update *BoardUpdate)
 }
 
 type GameState struct {
@@ -27,6 +30,14 @@ type GameState struct {
 
 	zobristHash Optional[uint64]
 
+	// The hash of each position before the current one, used to detect
+	// repetitions. Moves and null moves push onto this, undos pop from it.
+	hashHistory []uint64
+	// The index in hashHistory of each null move that hasn't been undone.
+	// Passing isn't legal, so positions before a null move can't be repeated
+	// after it.
+	nullMoves []int
+
 	moveListeners []MoveListener
 
 	noDefaultConstruction bool
@@ -73,6 +84,23 @@ func NewGameState(
 	return &game
 }
 
+// Clone returns an independent copy of the game which can be searched on
+// another goroutine. Move listeners are not copied.
+func (g *GameState) Clone() *GameState {
+	clone := NewGameState(
+		g.Board,
+		g.Player,
+		g.PlayerAndCastlingSideAllowed,
+		g.EnPassantTarget,
+		g.HalfMoveClock,
+		g.FullMoveClock,
+	)
+	clone.zobristHash = g.zobristHash
+	clone.hashHistory = append([]uint64{}, g.hashHistory...)
+	clone.nullMoves = append([]int{}, g.nullMoves...)
+	return clone
+}
+
 func (g *GameState) RegisterListener(listener MoveListener) func() {
 	g.moveListeners = append(g.moveListeners, listener)
 
@@ -91,6 +119,32 @@ func (g *GameState) ZobristHash() uint64 {
 	return g.zobristHash.Value()
 }
 
+// RepetitionCount returns how many times the current position has occurred
+// before. Only positions since the last capture, pawn move or null move are
+// considered because nothing before that can repeat.
+func (g *GameState) RepetitionCount() int {
+	hash := g.ZobristHash()
+
+	maxPlies := MinInt(g.HalfMoveClock, len(g.hashHistory))
+	if len(g.nullMoves) > 0 {
+		maxPlies = MinInt(maxPlies, len(g.hashHistory)-1-g.nullMoves[len(g.nullMoves)-1])
+	}
+
+	count := 0
+	for plies := 2; plies <= maxPlies; plies += 2 {
+		if g.hashHistory[len(g.hashHistory)-plies] == hash {
+			count++
+		}
+	}
+	return count
+}
+
+// IsFiftyMoveDraw checks whether fifty moves have passed by each player without
+// a capture or pawn move.
+func (g *GameState) IsFiftyMoveDraw() bool {
+	return g.HalfMoveClock >= 100
+}
+
 func isPawnCapture(startPieceType PieceType, startIndex int, endIndex int) bool {
 	if startPieceType != Pawn {
 		return false
@@ -157,6 +211,9 @@ func setupBoardUpdate(g *GameState, move Move, output *BoardUpdate) Error {
 	startPiece := g.Board[move.StartIndex]
 	startPlayer := startPiece.Player()
 
+	// Updates are reused once they've been undone
+	output.Num = 0
+
 	if startPiece.PieceType() == Pawn && move.PromotionPiece.HasValue() {
 		endPiece := PieceForPlayer[startPlayer][move.PromotionPiece.Value()]
 		output.Add(g.Board[move.StartIndex], move.StartIndex, XX)
@@ -260,9 +317,10 @@ func (g *GameState) PerformMove(move Move, update *BoardUpdate) Error {
 	g.Player = g.Player.Other()
 
 	g.zobristHash = Some(zobrist.UpdateHash(prevZobristHash, update, &g.PlayerAndCastlingSideAllowed, g.EnPassantTarget))
+	g.hashHistory = append(g.hashHistory, prevZobristHash)
 
 	for _, listener := range g.moveListeners {
-		listener.AfterMove(move)
+		listener.AfterMove(move, update)
 	}
 
 	return NilError
@@ -297,11 +355,71 @@ func (g *GameState) applyMoveToBitboards(update *BoardUpdate) Error {
 	return NilError
 }
 
+// PerformNullMove passes the turn to the other player without moving a piece.
+func (g *GameState) PerformNullMove(update *BoardUpdate) Error {
+	if !g.noDefaultConstruction {
+		return Errorf("GameState must be constructed with NewGameState")
+	}
+
+	prevZobristHash := g.ZobristHash()
+
+	*update = BoardUpdate{}
+	update.PrevPlayer = g.Player
+	update.PreviousCastlingRights = g.PlayerAndCastlingSideAllowed
+	update.PrevEnPassantTarget = g.EnPassantTarget
+	update.PrevFullMoveClock = g.FullMoveClock
+	update.PrevHalfMoveClock = g.HalfMoveClock
+
+	g.EnPassantTarget = Empty[FileRank]()
+	g.HalfMoveClock++
+	if g.Player == Black {
+		g.FullMoveClock++
+	}
+
+	g.Player = g.Player.Other()
+
+	g.zobristHash = Some(zobrist.UpdateHash(prevZobristHash, update, &g.PlayerAndCastlingSideAllowed, g.EnPassantTarget))
+	g.nullMoves = append(g.nullMoves, len(g.hashHistory))
+	g.hashHistory = append(g.hashHistory, prevZobristHash)
+
+	for _, listener := range g.moveListeners {
+		listener.AfterMove(Move{}, update)
+	}
+
+	return NilError
+}
+
+func (g *GameState) UndoNullMove(update *BoardUpdate) Error {
+	if update.Num != 0 {
+		return Errorf("expected a null move, found an update with %v squares", update.Num)
+	}
+	if len(g.nullMoves) > 0 {
+		g.nullMoves = g.nullMoves[:len(g.nullMoves)-1]
+	}
+	return g.UndoUpdate(update)
+}
+
 func (g *GameState) UndoUpdate(update *BoardUpdate) Error {
+	err := g.undoUpdate(update)
+	if !IsNil(err) {
+		return err
+	}
+
+	for _, listener := range g.moveListeners {
+		listener.AfterUndo(update)
+	}
+
+	return NilError
+}
+
+func (g *GameState) undoUpdate(update *BoardUpdate) Error {
 	if g.zobristHash.IsEmpty() {
 		return Errorf("zobrist hash should have been setup during original move")
 	}
 	g.zobristHash = Some(zobrist.UpdateHash(g.zobristHash.Value(), update, &g.PlayerAndCastlingSideAllowed, g.EnPassantTarget))
+	if len(g.hashHistory) > 0 {
+		g.hashHistory = g.hashHistory[:len(g.hashHistory)-1]
+	}
 
 	err := g.applyUndoToBitboards(update)
 	if !IsNil(err) {
@@ -321,10 +439,6 @@ func (g *GameState) UndoUpdate(update *BoardUpdate) Error {
 		g.Board[index] = piece
 	}
 
-	for _, listener := range g.moveListeners {
-		listener.AfterUndo()
-	}
-
 	return NilError
 }
 
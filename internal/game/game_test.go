@@ -87,3 +87,125 @@ func TestZobristHashSimple(t *testing.T) {
 
 	assert.Equal(t, hash0, hash2)
 }
+
+func TestCloneIsIndependent(t *testing.T) {
+	s := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	g, err := GamestateFromFenString(s)
+	assert.True(t, IsNil(err))
+
+	clone := g.Clone()
+	assert.Equal(t, g.ZobristHash(), clone.ZobristHash())
+	assert.Equal(t, FenStringForGame(g), FenStringForGame(clone))
+
+	update := BoardUpdate{}
+	err = clone.PerformMove(clone.MoveFromString("e1c1"), &update)
+	assert.True(t, IsNil(err))
+
+	assert.Equal(t, s, FenStringForGame(g))
+	assert.NotEqual(t, g.ZobristHash(), clone.ZobristHash())
+	assert.True(t, g.Bitboards.Players[White].Pieces[King]&SingleBitboard(BoardIndexFromString("e1")) != 0)
+}
+
+func TestNullMove(t *testing.T) {
+	s := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	g, err := GamestateFromFenString(s)
+	assert.True(t, IsNil(err))
+
+	hash0 := g.ZobristHash()
+
+	update := BoardUpdate{}
+	err = g.PerformNullMove(&update)
+	assert.True(t, IsNil(err))
+
+	assert.Equal(t, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 1 2", FenStringForGame(g))
+
+	hash1 := zobrist.HashForBoardPosition(&g.Board, g.Player, &g.PlayerAndCastlingSideAllowed, g.EnPassantTarget)
+	assert.Equal(t, hash1, g.ZobristHash())
+
+	err = g.UndoNullMove(&update)
+	assert.True(t, IsNil(err))
+
+	assert.Equal(t, s, FenStringForGame(g))
+	assert.Equal(t, hash0, g.ZobristHash())
+	assert.Equal(t, Some(FileRank{File: 4, Rank: 2}), g.EnPassantTarget)
+}
+
+func TestRepetitionCount(t *testing.T) {
+	g, err := GamestateFromFenString("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	assert.True(t, IsNil(err))
+
+	updates := []BoardUpdate{}
+	perform := func(moves ...string) {
+		for _, m := range moves {
+			updates = append(updates, BoardUpdate{})
+			err := g.PerformMove(g.MoveFromString(m), &updates[len(updates)-1])
+			assert.True(t, IsNil(err))
+		}
+	}
+	undo := func() {
+		err := g.UndoUpdate(&updates[len(updates)-1])
+		assert.True(t, IsNil(err))
+		updates = updates[:len(updates)-1]
+	}
+
+	assert.Equal(t, 0, g.RepetitionCount())
+
+	perform("g1f3", "g8f6", "f3g1")
+	assert.Equal(t, 0, g.RepetitionCount())
+	perform("f6g8")
+	assert.Equal(t, 1, g.RepetitionCount())
+	perform("g1f3", "g8f6", "f3g1", "f6g8")
+	assert.Equal(t, 2, g.RepetitionCount())
+
+	undo()
+	assert.Equal(t, 1, g.RepetitionCount())
+	perform("f6g8")
+	assert.Equal(t, 2, g.RepetitionCount())
+
+	// Repetitions are cloned
+	assert.Equal(t, 2, g.Clone().RepetitionCount())
+
+	// Pawn moves can't be undone, so earlier positions can't repeat
+	perform("e2e3", "e7e6", "g1f3", "g8f6", "f3g1", "f6g8")
+	assert.Equal(t, 1, g.RepetitionCount())
+}
+
+func TestRepetitionCountAfterNullMove(t *testing.T) {
+	g, err := GamestateFromFenString("4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+	assert.True(t, IsNil(err))
+
+	null := BoardUpdate{}
+	err = g.PerformNullMove(&null)
+	assert.True(t, IsNil(err))
+
+	updates := make([]BoardUpdate, 5)
+	for i, m := range []string{"e8d8", "h1h2", "d8d7", "h2h1", "d7e8"} {
+		err := g.PerformMove(g.MoveFromString(m), &updates[i])
+		assert.True(t, IsNil(err))
+	}
+
+	// The king triangulated back to the starting position, which can only
+	// happen because white passed
+	assert.Equal(t, "4k3/8/8/8/8/8/8/4K2R w - - 6 4", FenStringForGame(g))
+	assert.Equal(t, 0, g.RepetitionCount())
+	assert.Equal(t, 0, g.Clone().RepetitionCount())
+
+	for i := len(updates) - 1; i >= 0; i-- {
+		err := g.UndoUpdate(&updates[i])
+		assert.True(t, IsNil(err))
+	}
+	err = g.UndoNullMove(&null)
+	assert.True(t, IsNil(err))
+	assert.Equal(t, 0, len(g.nullMoves))
+}
+
+func TestFiftyMoveDraw(t *testing.T) {
+	g, err := GamestateFromFenString("8/8/4k3/8/8/4K3/8/7R w - - 99 80")
+	assert.True(t, IsNil(err))
+	assert.False(t, g.IsFiftyMoveDraw())
+
+	update := BoardUpdate{}
+	err = g.PerformMove(g.MoveFromString("h1h2"), &update)
+	assert.True(t, IsNil(err))
+	assert.True(t, g.IsFiftyMoveDraw())
+}
@@ -561,20 +561,14 @@ func InitialBounds() int {
 	return Inf + 1
 }
 
-func IsMate(score int) bool {
-	return score > Inf-100 || score < -Inf+100
-}
+// Mate scores count the plies from the root of the search to the mate, eg
+// Inf-1 means the current player mates with their next move and -Inf+2 means
+// the current player is mated after replying. Shorter mates score higher.
+// Tablebase mates can be a couple hundred plies away.
+const maxMatePlies = 512
 
-func IncrementMate(score int) (int, Error) {
-	if !IsMate(score) {
-		return score, Errorf("only increment mate scores)")
-	}
-
-	if score < 0 {
-		return score + 1, NilError
-	} else {
-		return score - 1, NilError
-	}
+func IsMate(score int) bool {
+	return score > Inf-maxMatePlies || score < -Inf+maxMatePlies
 }
 
 func MateWhiteWins() int {
@@ -585,31 +579,66 @@ func MateBlackWins() int {
 	return -Inf
 }
 
+// MateInNScore converts a UCI "mate N" score, where N counts full moves, eg
+// mate in 2 is 3 plies away and mate in -2 is 4 plies away.
 func MateInNScore(n int) (int, Error) {
 	if n == 0 {
 		return Inf, Errorf("not sure which direction for mate")
 	}
 	if n < 0 {
-		// mate in -1 should give -999998
-		// mate in -2 should give -999997
-		return -Inf + (-n), NilError
+		// mate in -1 should give -999997
+		// mate in -2 should give -999995
+		return -Inf + 2*(-n), NilError
 	} else {
 		// mate in 1 should give 999998
-		// mate in 2 should give 999997
-		return Inf - n, NilError
+		// mate in 2 should give 999996
+		return Inf - (2*n - 1), NilError
 	}
 }
 
-func ScoreString(score int) string {
-	if score > Inf-100 {
-		return fmt.Sprint("mate+", Inf-score)
+// MateInN is the inverse of MateInNScore. It returns 0 for scores that aren't
+// mates.
+func MateInN(score int) int {
+	if score > Inf-maxMatePlies {
+		plies := Inf - score
+		return (plies + 1) / 2
+	}
+	if score < -Inf+maxMatePlies {
+		plies := score + Inf
+		return -(plies + 1) / 2
 	}
-	if score < -Inf+100 {
-		return fmt.Sprint("mate-", -(-Inf - score))
+	return 0
+}
+
+// ScoreFromEnemyScore converts the score of the position after a move into the
+// score of the position before it. Mates are one ply further away.
+func ScoreFromEnemyScore(enemyScore int) int {
+	score := -enemyScore
+	if score > Inf-maxMatePlies {
+		return score - 1
+	} else if score < -Inf+maxMatePlies {
+		return score + 1
+	}
+	return score
+}
+
+func ScoreString(score int) string {
+	if n := MateInN(score); n > 0 {
+		return fmt.Sprint("mate+", n)
+	} else if n < 0 {
+		return fmt.Sprint("mate-", -n)
 	}
 	return fmt.Sprint(score)
 }
 
+// UciScoreString formats a score for a UCI "info" line, eg "cp 35" or "mate -2"
+func UciScoreString(score int) string {
+	if n := MateInN(score); n != 0 {
+		return fmt.Sprint("mate ", n)
+	}
+	return fmt.Sprint("cp ", score)
+}
+
 type Evaluation struct {
 	PlayerScore      int
 	PlayerIsInDanger bool
@@ -187,8 +187,8 @@ func ReduceSlice[T, U any](ts []T, initial U, f func(U, T) U) U {
 func MoveToFront[T any](ts *[]T, f func(T) bool) bool {
 	for i, t := range *ts {
 		if f(t) {
-			*ts = append((*ts)[:i], (*ts)[i+1:]...)
-			*ts = append([]T{t}, *ts...)
+			copy((*ts)[1:i+1], (*ts)[:i])
+			(*ts)[0] = t
 			return true
 		}
 	}
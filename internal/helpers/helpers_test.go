@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,3 +25,62 @@ func TestPrintColumns(t *testing.T) {
 	)
 	assert.Equal(t, "012 01234     0   ", result)
 }
+
+func TestMoveToFront(t *testing.T) {
+	ts := []int{1, 2, 3, 4, 5}
+	assert.True(t, MoveToFront(&ts, func(i int) bool { return i == 4 }))
+	assert.Equal(t, []int{4, 1, 2, 3, 5}, ts)
+
+	assert.False(t, MoveToFront(&ts, func(i int) bool { return i == 6 }))
+	assert.Equal(t, []int{4, 1, 2, 3, 5}, ts)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		MoveToFront(&ts, func(i int) bool { return i == 5 })
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestPoolReusesEveryBuffer(t *testing.T) {
+	get, release, stats := CreatePool(func() []int { return make([]int, 0, 4) }, func(t *[]int) { *t = (*t)[:0] })
+
+	// More buffers than the pool used to hold
+	buffers := []*[]int{}
+	for i := 0; i < 300; i++ {
+		buffer := get()
+		*buffer = append(*buffer, i)
+		buffers = append(buffers, buffer)
+	}
+	for _, buffer := range buffers {
+		release(buffer)
+	}
+
+	seen := map[*[]int]bool{}
+	for i := 0; i < 300; i++ {
+		buffer := get()
+		assert.Equal(t, 0, len(*buffer))
+		assert.False(t, seen[buffer])
+		seen[buffer] = true
+	}
+
+	assert.Equal(t, PoolStats{creates: 300, resets: 300, hits: 300}, stats())
+}
+
+func TestPoolConcurrently(t *testing.T) {
+	get, release, stats := CreatePool(func() []int { return []int{} }, func(t *[]int) { *t = (*t)[:0] })
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				release(get())
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := stats()
+	assert.Equal(t, 8000, s.creates+s.hits)
+	assert.Equal(t, 8000, s.resets)
+}
@@ -15,50 +15,44 @@ func (s PoolStats) String() string {
 	return fmt.Sprint("creates: ", s.creates, ", resets: ", s.resets, ", hits: ", s.hits)
 }
 
+// CreatePool returns functions to get, release and count buffers. It's safe to
+// use from multiple goroutines. Every released buffer is kept for reuse.
 func CreatePool[T any](create func() T, reset func(*T)) (func() *T, func(*T), func() PoolStats) {
-	availableBuffer := [256]*T{}
-	startIndex := 0
-	endIndex := 0
+	available := []*T{}
+	stats := PoolStats{}
 
 	lock := sync.Mutex{}
 
-	creates := 0
-	resets := 0
-	hits := 0
-
 	var get = func() *T {
 		lock.Lock()
-
-		if endIndex != startIndex {
-			result := availableBuffer[startIndex]
-			startIndex = (startIndex + 1) % 256
-
+		if len(available) > 0 {
+			result := available[len(available)-1]
+			available = available[:len(available)-1]
+			stats.hits++
 			lock.Unlock()
-
-			hits++
 			return result
 		}
-
+		stats.creates++
 		lock.Unlock()
 
-		creates++
 		result := create()
 		return &result
 	}
 
 	var release = func(t *T) {
-		resets++
 		reset(t)
 
 		lock.Lock()
-		availableBuffer[endIndex] = t
-		endIndex = (endIndex + 1) % 256
+		stats.resets++
+		available = append(available, t)
 		lock.Unlock()
 	}
 
-	var stats = func() PoolStats {
-		return PoolStats{creates, resets, hits}
+	var getStats = func() PoolStats {
+		lock.Lock()
+		defer lock.Unlock()
+		return stats
 	}
 
-	return get, release, stats
+	return get, release, getStats
 }
@@ -7,9 +7,36 @@ type Position struct {
 	Moves []string
 }
 
+// Clock is the state of a timed game. Times and increments are indexed by
+// Player.
+type Clock struct {
+	Remaining [2]time.Duration
+	Increment [2]time.Duration
+	MovesToGo Optional[int]
+}
+
 type SearchParams struct {
 	Depth    Optional[int]
 	Duration Optional[time.Duration]
+	// Stop after searching this many nodes, eg "go nodes 100000"
+	Nodes Optional[int]
+	// Only search deep enough to find a mate in this many moves, eg "go mate 3"
+	Mate Optional[int]
+	// Search until told to stop, eg "go infinite"
+	Infinite bool
+
+	// The engine decides how long to think for, see search.TimeManager
+	Clock Optional[Clock]
+
+	// Search on the opponent's time. The limits above only start to apply
+	// once the opponent plays the expected move.
+	Ponder bool
+
+	// Only search these root moves, eg "go searchmoves e2e4 d2d4"
+	SearchMoves []string
+	// Search every root move but these, eg to find the best move other than
+	// the obvious recapture
+	ExcludeMoves []string
 }
 
 type Runner interface {
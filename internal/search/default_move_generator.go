@@ -10,23 +10,19 @@ type DefaultMoveGenerator struct {
 
 var _ MoveGen = (*DefaultMoveGenerator)(nil)
 
-func (gen *DefaultMoveGenerator) generateMoves(g *game.GameState, mode MoveGenerationMode) (func(), MoveGenerationResult, *[]Move, Error) {
-
-	moves := GetMovesBuffer()
-	cleanup := func() { ReleaseMovesBuffer(moves) }
+func NewDefaultMoveGenerator() *DefaultMoveGenerator {
+	return &DefaultMoveGenerator{}
+}
 
-	result := AllLegalMoves
+func (gen *DefaultMoveGenerator) generateMoves(g *game.GameState, mode MoveGenerationMode, moves *[]Move) (MoveGenerationResult, Error) {
+	*moves = (*moves)[:0]
+	GeneratePseudoMovesForMode(func(m Move) {
+		*moves = append(*moves, m)
+	}, g, mode)
 
-	if mode == OnlyCaptures {
-		result = SomeLegalMoves
-		GeneratePseudoCaptures(func(m Move) {
-			*moves = append(*moves, m)
-		}, g)
-	} else {
-		GeneratePseudoMoves(func(m Move) {
-			*moves = append(*moves, m)
-		}, g)
-	}
+	return AllLegalMoves, NilError
+}
 
-	return cleanup, result, moves, NilError
+func (gen *DefaultMoveGenerator) isPseudoLegal(g *game.GameState, move Move) bool {
+	return IsPseudoLegal(g, move)
 }
@@ -0,0 +1,36 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// isDraw checks for draws that depend on the game history rather than the
+// board. A single repetition is scored as a draw: if repeating was the best
+// option once, it will be the best option again.
+func (helper *SearchHelper) isDraw(currentDepth int) (bool, Error) {
+	if helper.GameState.RepetitionCount() > 0 {
+		return true, NilError
+	}
+	if !helper.GameState.IsFiftyMoveDraw() {
+		return false, NilError
+	}
+	if !helper.inCheck() {
+		return true, NilError
+	}
+
+	// Checkmate takes precedence over the fifty-move rule
+	moves := &helper.ply(currentDepth).moves
+	*moves = (*moves)[:0]
+	err := GenerateLegalMoves(helper.GameState, moves)
+	return len(*moves) > 0, err
+}
+
+// drawScore is from the perspective of the player to move. A positive Contempt
+// means the player at the root of the search prefers to keep playing rather
+// than accept a draw.
+func (helper *SearchHelper) drawScore(currentDepth int) int {
+	if currentDepth%2 == 0 {
+		return -helper.Contempt
+	}
+	return helper.Contempt
+}
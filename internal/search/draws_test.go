@@ -0,0 +1,81 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func searchAfterMoves(t *testing.T, fen string, moves []string, options SearchOptions) ([]Move, int) {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	for _, m := range moves {
+		update := BoardUpdate{}
+		err = g.PerformMove(g.MoveFromString(m), &update)
+		assert.True(t, IsNil(err), err)
+	}
+
+	unregister, helper := NewSearchHelper(g, options)
+	defer unregister()
+
+	result, err := helper.Search()
+	assert.True(t, IsNil(err), err)
+	return result.PV, result.Score
+}
+
+func TestSearchRepeatsWhenLosing(t *testing.T) {
+	// White is down a queen and a rook, but can repeat the position
+	fen := "7k/8/8/8/3q4/8/3r4/1N5K w - - 0 1"
+	moves := []string{"b1a3", "h8g8", "a3b1", "g8h8"}
+
+	pv, score := searchAfterMoves(t, fen, moves, SearchOptions{MaxDepth: Some(1)})
+	assert.Equal(t, "b1a3", pv[0].String())
+	assert.Equal(t, 0, score)
+
+	// Without the history, there's no draw to be found
+	_, score = searchAfterMoves(t, fen, nil, SearchOptions{MaxDepth: Some(1)})
+	assert.Less(t, score, -500)
+
+	// With enough contempt, losing is preferred to drawing
+	pv, score = searchAfterMoves(t, fen, moves, SearchOptions{MaxDepth: Some(1), Contempt: 5000})
+	assert.NotEqual(t, "b1a3", pv[0].String())
+	assert.Less(t, score, -500)
+}
+
+func TestFiftyMoveDrawInSearch(t *testing.T) {
+	// White is up a rook, but any move except a pawn move ends the game in a draw
+	fen := "7k/8/8/8/8/8/P7/K6R w - - 99 80"
+
+	pv, score := searchAfterMoves(t, fen, nil, SearchOptions{MaxDepth: Some(1)})
+	assert.Equal(t, "a2", StringFromBoardIndex(pv[0].StartIndex))
+	assert.Greater(t, score, 400)
+}
+
+func TestCheckmateOnTheHundredthHalfMove(t *testing.T) {
+	// Ra8 is mate, and also the hundredth half move without a capture or pawn move
+	fen := "7k/8/6K1/8/8/8/8/R7 w - - 99 80"
+
+	pv, score := searchAfterMoves(t, fen, nil, SearchOptions{MaxDepth: Some(1)})
+	assert.Equal(t, "a1a8", pv[0].String())
+	assert.True(t, IsMate(score), ScoreString(score))
+}
+
+// drawnTablebase scores every position as a draw
+type drawnTablebase struct{}
+
+func (drawnTablebase) Probe(g *game.GameState) Optional[TablebaseResult] {
+	return Some(TablebaseResult{Outcome: Draw})
+}
+
+func TestTablebaseDrawsUseContempt(t *testing.T) {
+	fen := "7k/8/8/8/8/8/8/K6R w - - 0 1"
+
+	_, score := searchAfterMoves(t, fen, nil, SearchOptions{MaxDepth: Some(2), Tablebase: Some[Tablebase](drawnTablebase{})})
+	assert.Equal(t, 0, score)
+
+	_, score = searchAfterMoves(t, fen, nil, SearchOptions{MaxDepth: Some(2), Tablebase: Some[Tablebase](drawnTablebase{}), Contempt: 50})
+	assert.Equal(t, -50, score)
+}
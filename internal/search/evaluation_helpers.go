@@ -159,13 +159,29 @@ var PawnCenterBitboards = [2]Bitboard{
 	}),
 }
 
-func EvaluateDevelopment(b *Bitboards, player Player) int {
-	development := 0
-	development += evaluateDevelopmentForPiece(b.Players[player].Pieces[Rook], RookDevelopmentBitboards[player])
-	development += evaluateDevelopmentForPiece(b.Players[player].Pieces[Knight], KnightDevelopmentBitboards[player])
-	development += evaluateDevelopmentForPiece(b.Players[player].Pieces[Bishop], BishopDevelopmentBitboards[player])
-	development += evaluateDevelopmentForPiece(b.Players[player].Pieces[Pawn], PawnDevelopmentBitboards[player])
+// _developmentBitboards are the piece-square tables used by EvaluateDevelopment
+var _developmentBitboards = [InvalidPiece][2][]EvaluationBitboard{
+	Rook:   RookDevelopmentBitboards,
+	Knight: KnightDevelopmentBitboards,
+	Bishop: BishopDevelopmentBitboards,
+	Pawn:   PawnDevelopmentBitboards,
+}
 
+// _developmentSquareValues is _developmentBitboards for single squares, eg for
+// updating IncrementalEvaluator
+var _developmentSquareValues = func() [2][InvalidPiece][64]int {
+	result := [2][InvalidPiece][64]int{}
+	for _, player := range []Player{White, Black} {
+		for pieceType, evaluations := range _developmentBitboards {
+			for i := 0; i < 64; i++ {
+				result[player][pieceType][i] = evaluateDevelopmentForPiece(SingleBitboard(i), evaluations[player])
+			}
+		}
+	}
+	return result
+}()
+
+func evaluatePawnCenter(b *Bitboards, player Player) int {
 	pawnsInCenter := 0
 	for _, pawnCenter := range PawnCenterBitboards {
 		if pawnCenter&b.Players[player].Pieces[Pawn] != 0 {
@@ -173,36 +189,43 @@ func EvaluateDevelopment(b *Bitboards, player Player) int {
 		}
 	}
 	if pawnsInCenter == 2 {
-		development += _developmentScale * 2
+		return _developmentScale * 2
 	} else if pawnsInCenter == 1 {
-		development += _developmentScale * 1
+		return _developmentScale * 1
 	}
-
-	return development
+	return 0
 }
 
-func EvaluatePieces(b *Bitboards, player Player) int {
-	pieceValues :=
-		500*OnesCount(b.Players[player].Pieces[Rook]) +
-			300*OnesCount(b.Players[player].Pieces[Knight]) +
-			350*OnesCount(b.Players[player].Pieces[Bishop]) +
-			900*OnesCount(b.Players[player].Pieces[Queen]) +
-			100*OnesCount(b.Players[player].Pieces[Pawn])
+func EvaluateDevelopment(b *Bitboards, player Player) int {
+	development := 0
+	for pieceType, evaluations := range _developmentBitboards {
+		development += evaluateDevelopmentForPiece(b.Players[player].Pieces[pieceType], evaluations[player])
+	}
 
-	return pieceValues
+	return development + evaluatePawnCenter(b, player)
 }
 
-func Evaluate(b *Bitboards, player Player, args ...EvaluationOption) int {
-	enemy := player.Other()
-
-	developmentValues := EvaluateDevelopment(b, player)
-	enemyDevelopmentValues := EvaluateDevelopment(b, enemy)
+var _pieceValues = [InvalidPiece]int{
+	Rook:   500,
+	Knight: 300,
+	Bishop: 350,
+	Queen:  900,
+	Pawn:   100,
+}
 
-	pieceValues := EvaluatePieces(b, player)
-	enemyPieceValues := EvaluatePieces(b, enemy)
+func EvaluatePieces(b *Bitboards, player Player) int {
+	pieceValues := 0
+	for pieceType, value := range _pieceValues {
+		pieceValues += value * OnesCount(b.Players[player].Pieces[pieceType])
+	}
 
-	result := pieceValues - enemyPieceValues + developmentValues - enemyDevelopmentValues
+	return pieceValues
+}
 
+// evaluateEndgame pushes the enemy king towards the edge once the enemy has
+// little material left
+func evaluateEndgame(b *Bitboards, enemy Player, enemyPieceValues int) int {
+	result := 0
 	if enemyPieceValues <= 500 {
 		result += evaluateDevelopmentForPiece(
 			b.Players[enemy].Pieces[King],
@@ -211,22 +234,21 @@ func Evaluate(b *Bitboards, player Player, args ...EvaluationOption) int {
 			result += 10
 		}
 	}
-
 	return result
 }
 
-var _pieceScores = []int{
-	500,
-	300,
-	350,
-	0,
-	900,
-	100,
-	0,
-}
+func Evaluate(b *Bitboards, player Player, args ...EvaluationOption) int {
+	enemy := player.Other()
+
+	developmentValues := EvaluateDevelopment(b, player)
+	enemyDevelopmentValues := EvaluateDevelopment(b, enemy)
+
+	pieceValues := EvaluatePieces(b, player)
+	enemyPieceValues := EvaluatePieces(b, enemy)
 
-func pieceScore(g *GameState, index int) int {
-	return _pieceScores[g.Board[index].PieceType()]
+	result := pieceValues - enemyPieceValues + developmentValues - enemyDevelopmentValues
+
+	return result + evaluateEndgame(b, enemy, enemyPieceValues)
 }
 
 type EvaluationOption int
@@ -237,11 +259,8 @@ const (
 
 func EvaluateMove(m *Move, g *GameState, args ...EvaluationOption) int {
 	score := 0
-	if m.MoveType == CaptureMove {
-		score += pieceScore(g, m.EndIndex) - pieceScore(g, m.StartIndex)
-	}
-	if m.MoveType == EnPassantMove {
-		score += 100
+	if m.MoveType.Captures() {
+		score += SEE(g, *m)
 	}
 	if m.MoveType == CastlingMove {
 		score += 200
@@ -19,7 +19,23 @@ var CreateBasicEvaluator EvaluatorConstructor = func(game *game.GameState) (func
 }
 
 func (e BasicEvaluator) evaluate(helper *SearchHelper, player Player, alpha int, beta int, currentDepth int, pastMoves []SearchMove) ([]SearchMove, int, Error) {
-	return nil, Evaluate(helper.GameState.Bitboards, player), NilError
+	score, err := helper.evaluate(player)
+	return nil, score, err
+}
+
+// evaluate adds the Skill's noise to the static evaluation
+func (helper *SearchHelper) evaluate(player Player) (int, Error) {
+	score := 0
+	if helper.incremental != nil {
+		var err Error
+		score, err = helper.incremental.Evaluate(player)
+		if err.HasError() {
+			return score, err
+		}
+	} else {
+		score = Evaluate(helper.GameState.Bitboards, player)
+	}
+	return score + helper.skillNoise(player), NilError
 }
 
 type QuiescenceEvaluator struct {
@@ -28,33 +44,11 @@ type QuiescenceEvaluator struct {
 var _ Evaluator = (*QuiescenceEvaluator)(nil)
 
 func (e QuiescenceEvaluator) evaluate(helper *SearchHelper, player Player, alpha int, beta int, currentDepth int, pastMoves []SearchMove) ([]SearchMove, int, Error) {
-	if len(pastMoves) > 0 {
-		lastMove := pastMoves[len(pastMoves)-1]
-		if !lastMove.MoveType.Captures() {
-			return nil, Evaluate(helper.GameState.Bitboards, player), NilError
-		}
-	}
-
 	prevInQuiescence := helper.InQuiescence
-	prevEvaluator := helper.Evaluator
-	prevSorter := helper.MoveSorter
-
 	helper.InQuiescence = true
-	helper.Evaluator = BasicEvaluator{}
-	helper.MoveSorter = helper.MoveSorter.copy()
-
 	defer func() {
 		helper.InQuiescence = prevInQuiescence
-		helper.Evaluator = prevEvaluator
-		helper.MoveSorter = prevSorter
 	}()
 
-	quiescenceDepth := helper.MaxDepth.ValueOr(defaultMaxDepth) * 8
-	// quiescenceDepth := 10
-
-	moves, score, err := helper.alphaBeta(alpha, beta, currentDepth,
-		quiescenceDepth,
-		pastMoves,
-	)
-	return moves, score, err
+	return helper.quiescence(alpha, beta, currentDepth, 0, pastMoves)
 }
@@ -0,0 +1,143 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/bitboards"
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+const (
+	defaultMaxExtensionPlies = 4
+
+	singularMinDepth       = 6
+	singularMaxDepthTooLow = 3
+	singularMarginPerDepth = 20
+)
+
+// extension returns how many extra plies to search `move`, which has just been
+// applied. Forcing moves are extended so that they don't hit the horizon as
+// early as quiet moves. Each line is extended by at most MaxExtensionPlies.
+func (helper *SearchHelper) extension(move Move, past []SearchMove, givesCheck bool, singular bool) int {
+	if helper.InQuiescence {
+		return 0
+	}
+	if helper.extensionPlies >= helper.MaxExtensionPlies.ValueOr(defaultMaxExtensionPlies) {
+		return 0
+	}
+
+	if givesCheck && !helper.WithoutCheckExtensions {
+		return 1
+	}
+
+	if helper.RecaptureExtensions && move.MoveType.Captures() && len(past) > 0 {
+		previous := past[len(past)-1]
+		if previous.MoveType.Captures() && previous.EndIndex == move.EndIndex {
+			return 1
+		}
+	}
+
+	if !helper.WithoutPassedPawnExtensions && isPassedPawnPush(helper.GameState, move) {
+		return 1
+	}
+
+	if singular {
+		return 1
+	}
+
+	return 0
+}
+
+// isPassedPawnPush checks whether `move` (which has just been applied) pushed a
+// pawn to the 7th rank with no enemy pawns able to stop it.
+func isPassedPawnPush(g *GameState, move Move) bool {
+	if move.PromotionPiece.HasValue() || g.Board[move.EndIndex].PieceType() != Pawn {
+		return false
+	}
+
+	player := g.Player.Other()
+	location := FileRankFromIndex(move.EndIndex)
+
+	seventhRank := Rank(6)
+	if player == Black {
+		seventhRank = 1
+	}
+	if location.Rank != seventhRank {
+		return false
+	}
+
+	// The only square left in front of the pawn is on the promotion rank
+	promotionIndex := move.EndIndex + PawnPushOffsets[player]
+	blockers := SingleBitboard(promotionIndex)
+	if location.File > 0 {
+		blockers |= SingleBitboard(promotionIndex - 1)
+	}
+	if location.File < 7 {
+		blockers |= SingleBitboard(promotionIndex + 1)
+	}
+
+	return blockers&g.Bitboards.Players[g.Player].Pieces[Pawn] == 0
+}
+
+func (helper *SearchHelper) canTrySingularExtension(hashMove Optional[Move], depthRemaining int) bool {
+	if helper.WithoutSingularExtensions || helper.InQuiescence || helper.inNullMove || helper.inSingularSearch {
+		return false
+	}
+	return hashMove.HasValue() && depthRemaining >= singularMinDepth
+}
+
+// isSingular checks whether the hash move is much better than every other move
+// in the position. Each alternative is searched at half depth against a bound
+// just below the cached score of the hash move. If none of them reach it, the
+// hash move is singular and worth searching deeper.
+func (helper *SearchHelper) isSingular(hash uint64, hashMove Move, currentDepth int, depthRemaining int, past []SearchMove) (bool, Error) {
+	cached := helper.TranspositionTable.load(hash)
+	if cached.IsEmpty() {
+		return false, NilError
+	}
+
+	entry := cached.Value()
+	if entry.BestMove != Some(hashMove) || entry.ScoreType == AlphaFailUpperBound ||
+		entry.Depth < depthRemaining-singularMaxDepthTooLow || IsMate(entry.Score) {
+		return false, NilError
+	}
+
+	singularBeta := scoreFromTranspositionTable(entry.Score, currentDepth) - singularMarginPerDepth*depthRemaining
+
+	moves := &helper.ply(currentDepth).moves
+	_, err := helper.MoveGen.generateMoves(helper.GameState, AllMoves, moves)
+	if err.HasError() {
+		return false, err
+	}
+
+	helper.inSingularSearch = true
+	defer func() { helper.inSingularSearch = false }()
+
+	for _, move := range *moves {
+		if move == hashMove {
+			continue
+		}
+
+		legal, err := helper.performMove(currentDepth, move)
+		if err.HasError() {
+			return false, err
+		}
+
+		score := 0
+		if legal {
+			var enemyScore int
+			_, enemyScore, err = helper.alphaBeta(-singularBeta, -singularBeta+1, currentDepth+1, depthRemaining/2-1, append(past, SearchMove{move, false}))
+			score = -enemyScore
+		}
+
+		undoErr := helper.undoMove(currentDepth)
+		if err.HasError() || undoErr.HasError() {
+			return false, Join(err, undoErr)
+		}
+
+		if legal && score >= singularBeta {
+			return false, NilError
+		}
+	}
+
+	return true, NilError
+}
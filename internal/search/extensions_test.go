@@ -0,0 +1,89 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckExtensionFindsMate(t *testing.T) {
+	fen := "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"
+
+	// Quiescence searches check evasions, so it would also find the mate
+	result, score, err := Search(fen, SearchOptions{MaxDepth: Some(1), CreateEvaluator: Some(CreateBasicEvaluator)})
+	assert.True(t, IsNil(err), err)
+	assert.True(t, IsMate(score), ScoreString(score))
+	assert.Equal(t, "a1a8", result[0].String())
+
+	_, score, err = Search(fen, SearchOptions{MaxDepth: Some(1), CreateEvaluator: Some(CreateBasicEvaluator), WithoutCheckExtensions: true})
+	assert.True(t, IsNil(err), err)
+	assert.False(t, IsMate(score), ScoreString(score))
+}
+
+func TestExtensions(t *testing.T) {
+	type testCase struct {
+		fen      string
+		past     []string
+		move     string
+		moveType MoveType
+		expected int
+	}
+
+	cases := []testCase{
+		// quiet move
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", nil, "a1a7", QuietMove, 0},
+		// check
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", nil, "a1a8", QuietMove, 1},
+		// recapture
+		{"6k1/5ppp/8/3p4/4P3/8/8/3R2K1 w - - 0 1", []string{"c6d5"}, "e4d5", CaptureMove, 1},
+		{"6k1/5ppp/8/3p4/4P3/8/8/3R2K1 w - - 0 1", []string{"c6c5"}, "e4d5", CaptureMove, 0},
+		// passed pawn reaching the 7th rank
+		{"6k1/8/2P5/8/8/8/8/6K1 w - - 0 1", nil, "c6c7", QuietMove, 1},
+		{"1p4k1/8/2P5/8/8/8/8/6K1 w - - 0 1", nil, "c6c7", QuietMove, 0},
+		{"6k1/8/8/8/8/2p5/8/6K1 b - - 0 1", nil, "c3c2", QuietMove, 1},
+	}
+
+	for _, c := range cases {
+		g, err := game.GamestateFromFenString(c.fen)
+		assert.True(t, IsNil(err), err)
+
+		unregister, helper := NewSearchHelper(g, SearchOptions{RecaptureExtensions: true})
+		defer unregister()
+
+		past := MapSlice(c.past, func(s string) SearchMove {
+			return SearchMove{MoveFromString(s, CaptureMove), false}
+		})
+
+		undo, legal, err := performMoveAndReturnLegality(g, MoveFromString(c.move, c.moveType))
+		assert.True(t, IsNil(err), err)
+		assert.True(t, legal)
+
+		assert.Equal(t, c.expected, helper.extension(MoveFromString(c.move, c.moveType), past, helper.inCheck(), false), c.fen+" "+c.move)
+
+		// each line can only be extended so far
+		helper.extensionPlies = defaultMaxExtensionPlies
+		assert.Equal(t, 0, helper.extension(MoveFromString(c.move, c.moveType), past, helper.inCheck(), false), c.fen+" "+c.move)
+
+		assert.True(t, IsNil(undo()))
+	}
+}
+
+func TestRecaptureExtensionsAreOptIn(t *testing.T) {
+	g, err := game.GamestateFromFenString("6k1/5ppp/8/3p4/4P3/8/8/3R2K1 w - - 0 1")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{})
+	defer unregister()
+
+	past := []SearchMove{{MoveFromString("c6d5", CaptureMove), false}}
+	move := MoveFromString("e4d5", CaptureMove)
+
+	undo, legal, err := performMoveAndReturnLegality(g, move)
+	assert.True(t, IsNil(err), err)
+	assert.True(t, legal)
+	defer undo()
+
+	assert.Equal(t, 0, helper.extension(move, past, helper.inCheck(), false))
+}
@@ -12,7 +12,8 @@ func generateWalkMovesWithMagic(
 	allOccupied Bitboard,
 	selfOccupied Bitboard,
 	magicTable MagicMoveTable,
-	onlyCaptures bool,
+	quietTargets Bitboard,
+	captureTargets Bitboard,
 ) {
 	startIndex, tempPieces := 0, Bitboard(pieces)
 	for tempPieces != 0 {
@@ -28,15 +29,15 @@ func generateWalkMovesWithMagic(
 		quiet := potential & ^allOccupied
 		capture := potential & ^quiet
 
-		if !onlyCaptures {
-			endIndex, tempQuiet := 0, Bitboard(quiet)
+		{
+			endIndex, tempQuiet := 0, Bitboard(quiet&quietTargets)
 			for tempQuiet != 0 {
 				endIndex, tempQuiet = tempQuiet.NextIndexOfOne()
 				f(Move{MoveType: QuietMove, StartIndex: startIndex, EndIndex: endIndex})
 			}
 		}
 		{
-			captureIndex, tempCapture := 0, Bitboard(capture)
+			captureIndex, tempCapture := 0, Bitboard(capture&captureTargets)
 			for tempCapture != 0 {
 				captureIndex, tempCapture = tempCapture.NextIndexOfOne()
 
@@ -52,7 +53,8 @@ func generateJumpMovesByLookup(
 	allOccupied Bitboard,
 	selfOccupied Bitboard,
 	attackMasks [64]Bitboard,
-	onlyCaptures bool,
+	quietTargets Bitboard,
+	captureTargets Bitboard,
 ) {
 	startIndex, tempPieces := 0, Bitboard(pieces)
 	for tempPieces != 0 {
@@ -64,15 +66,15 @@ func generateJumpMovesByLookup(
 		quiet := potential & ^allOccupied
 		capture := potential & ^quiet
 
-		if !onlyCaptures {
-			endIndex, tempQuiet := 0, Bitboard(quiet)
+		{
+			endIndex, tempQuiet := 0, Bitboard(quiet&quietTargets)
 			for tempQuiet != 0 {
 				endIndex, tempQuiet = tempQuiet.NextIndexOfOne()
 				f(Move{MoveType: QuietMove, StartIndex: startIndex, EndIndex: endIndex})
 			}
 		}
 		{
-			captureIndex, tempCapture := 0, Bitboard(capture)
+			captureIndex, tempCapture := 0, Bitboard(capture&captureTargets)
 			for tempCapture != 0 {
 				captureIndex, tempCapture = tempCapture.NextIndexOfOne()
 
@@ -82,19 +84,53 @@ func generateJumpMovesByLookup(
 	}
 }
 
-var GetMovesBuffer, ReleaseMovesBuffer, StatsMoveBuffer = CreatePool(func() []Move { return make([]Move, 0, 256) }, func(t *[]Move) { *t = (*t)[:0] })
+func CreateMovesBufferPool() (func() *[]Move, func(*[]Move), func() PoolStats) {
+	return CreatePool(func() []Move { return make([]Move, 0, 256) }, func(t *[]Move) { *t = (*t)[:0] })
+}
+
+var GetMovesBuffer, ReleaseMovesBuffer, StatsMoveBuffer = CreateMovesBufferPool()
 
 func GeneratePseudoMoves(f func(move Move), g *GameState) {
-	GeneratePseudoMovesInternal(f, g, false /* onlyCaptures */, false /* allPossiblePromotions */, false /*skipCastling*/)
+	GeneratePseudoMovesInternal(f, g, AllMoves, false /* allPossiblePromotions */, false /*skipCastling*/)
 }
 func GeneratePseudoMovesWithAllPromotions(f func(move Move), g *GameState) {
-	GeneratePseudoMovesInternal(f, g, false /* onlyCaptures */, true /* allPossiblePromotions */, false /*skipCastling*/)
+	GeneratePseudoMovesInternal(f, g, AllMoves, true /* allPossiblePromotions */, false /*skipCastling*/)
 }
 func GeneratePseudoMovesSkippingCastling(f func(move Move), g *GameState) {
-	GeneratePseudoMovesInternal(f, g, false /* onlyCaptures */, true /* allPossiblePromotions */, true /*skipCastling*/)
+	GeneratePseudoMovesInternal(f, g, AllMoves, true /* allPossiblePromotions */, true /*skipCastling*/)
 }
 func GeneratePseudoCaptures(f func(move Move), g *GameState) {
-	GeneratePseudoMovesInternal(f, g, true /* onlyCaptures */, false /* allPossiblePromotions */, true /* skipCastling */)
+	GeneratePseudoMovesInternal(f, g, OnlyCaptures, false /* allPossiblePromotions */, true /* skipCastling */)
+}
+
+// GeneratePseudoMovesForMode generates the moves for `mode`, see
+// MoveGenerationMode. Castling is only generated for AllMoves and QuietMoves.
+func GeneratePseudoMovesForMode(f func(move Move), g *GameState, mode MoveGenerationMode) {
+	GeneratePseudoMovesInternal(f, g, mode, false /* allPossiblePromotions */, false /* skipCastling */)
+}
+
+// IsPseudoLegal checks whether `move` would be generated in this position, so
+// that eg a move from the transposition table can be tried without
+// generating every move
+func IsPseudoLegal(g *GameState, move Move) bool {
+	start := g.Board[move.StartIndex]
+	if start == XX || start.Player() != g.Player {
+		return false
+	}
+
+	end := SingleBitboard(move.EndIndex)
+	targets := moveTargets{
+		from:     SingleBitboard(move.StartIndex),
+		quiet:    quietTargets{end, end, end, end, end, end},
+		captures: end,
+		castling: move.MoveType == CastlingMove,
+	}
+
+	found := false
+	generatePseudoMoves(func(m Move) {
+		found = found || m == move
+	}, g, targets, true /* allPossiblePromotions */)
+	return found
 }
 
 var possiblePromotions = []PieceType{Queen, Rook, Bishop, Knight}
@@ -127,14 +163,92 @@ func appendPawnMovesAndPossiblePromotions(f func(move Move), moveType MoveType,
 	}
 }
 
-func GeneratePseudoMovesInternal(f func(move Move), g *GameState, onlyCaptures bool, allPossiblePromotions bool, skipCastling bool) {
+var _promotionSquares = [2]Bitboard{^MaskN, ^MaskS}
+
+// quietTargets are the squares that each kind of piece can make quiet moves
+// to. Captures are always generated.
+type quietTargets struct {
+	pawn   Bitboard
+	knight Bitboard
+	bishop Bitboard
+	rook   Bitboard
+	queen  Bitboard
+	king   Bitboard
+}
+
+// moveTargets restricts which moves are generated
+type moveTargets struct {
+	// Only the pieces on these squares are moved
+	from     Bitboard
+	quiet    quietTargets
+	captures Bitboard
+	castling bool
+}
+
+func moveTargetsForMode(g *GameState, mode MoveGenerationMode) moveTargets {
+	switch mode {
+	case AllMoves:
+		return moveTargets{AllOnes, quietTargets{AllOnes, AllOnes, AllOnes, AllOnes, AllOnes, AllOnes}, AllOnes, true}
+	case CapturesAndPromotions:
+		return moveTargets{AllOnes, quietTargets{pawn: _promotionSquares[g.Player]}, AllOnes, false}
+	case CapturesPromotionsAndChecks:
+		return moveTargets{AllOnes, quietChecks(g), AllOnes, false}
+	case QuietMoves:
+		return moveTargets{AllOnes, quietTargets{^_promotionSquares[g.Player], AllOnes, AllOnes, AllOnes, AllOnes, AllOnes}, 0, true}
+	}
+	return moveTargets{AllOnes, quietTargets{}, AllOnes, false}
+}
+
+// quietChecks are the squares that give direct check to the enemy king.
+// Discovered checks and castling into check aren't included.
+func quietChecks(g *GameState) quietTargets {
+	b := g.Bitboards
+	enemy := g.Player.Other()
+
+	kingBoard := b.Players[enemy].Pieces[King]
+	if kingBoard == 0 {
+		return quietTargets{pawn: _promotionSquares[g.Player]}
+	}
+	kingIndex := kingBoard.FirstIndexOfOne()
+
+	// Our pawns give check from the squares an enemy pawn on the king's
+	// square would attack
+	pawnChecks := Bitboard(0)
+	for _, captureOffset := range PawnCaptureOffsets[enemy] {
+		pawnChecks |= RotateTowardsIndex64(kingBoard&PremoveMaskFromOffset(captureOffset), captureOffset)
+	}
+
+	bishopChecks := BishopMagicTable.Attacks(kingIndex, b.Occupied)
+	rookChecks := RookMagicTable.Attacks(kingIndex, b.Occupied)
+
+	return quietTargets{
+		pawn:   pawnChecks | _promotionSquares[g.Player],
+		knight: KnightAttackMasks[kingIndex],
+		bishop: bishopChecks,
+		rook:   rookChecks,
+		queen:  bishopChecks | rookChecks,
+	}
+}
+
+func GeneratePseudoMovesInternal(f func(move Move), g *GameState, mode MoveGenerationMode, allPossiblePromotions bool, skipCastling bool) {
+	targets := moveTargetsForMode(g, mode)
+	targets.castling = targets.castling && !skipCastling
+	generatePseudoMoves(f, g, targets, allPossiblePromotions)
+}
+
+func generatePseudoMoves(f func(move Move), g *GameState, targets moveTargets, allPossiblePromotions bool) {
 	player := g.Player
 	b := g.Bitboards
 
 	playerBoards := b.Players[player]
 	enemyBoards := &b.Players[player.Other()]
 
-	if !onlyCaptures && !skipCastling {
+	pieces := playerBoards.Pieces
+	for i := range pieces {
+		pieces[i] &= targets.from
+	}
+
+	if targets.castling {
 		// generate king castle
 		for _, castlingSide := range AllCastlingSides {
 			canCastle := true
@@ -150,7 +264,7 @@ func GeneratePseudoMovesInternal(f func(move Move), g *GameState, onlyCaptures b
 					}
 				}
 
-				if canCastle {
+				if canCastle && targets.from&SingleBitboard(requirements.Move.StartIndex) != 0 {
 					f(requirements.Move)
 				}
 			}
@@ -160,9 +274,9 @@ func GeneratePseudoMovesInternal(f func(move Move), g *GameState, onlyCaptures b
 		pushOffset := PawnPushOffsets[player]
 
 		// generate one step
-		if !onlyCaptures {
-			potential := RotateTowardsIndex64(playerBoards.Pieces[Pawn]&PremoveMaskFromOffset(pushOffset), pushOffset)
-			potential = potential & ^b.Occupied
+		{
+			potential := RotateTowardsIndex64(pieces[Pawn]&PremoveMaskFromOffset(pushOffset), pushOffset)
+			potential = potential & ^b.Occupied & targets.quiet.pawn
 
 			index, tempPotential := 0, Bitboard(potential)
 			for tempPotential != 0 {
@@ -172,13 +286,13 @@ func GeneratePseudoMovesInternal(f func(move Move), g *GameState, onlyCaptures b
 		}
 
 		// generate skip step
-		if !onlyCaptures {
-			potential := playerBoards.Pieces[Pawn]
+		if targets.quiet.pawn != 0 {
+			potential := pieces[Pawn]
 			potential = potential & MaskStartingPawnsForPlayer(player)
 			potential = RotateTowardsIndex64(potential, pushOffset)
 			potential = potential & ^b.Occupied
 			potential = RotateTowardsIndex64(potential, pushOffset)
-			potential = potential & ^b.Occupied
+			potential = potential & ^b.Occupied & targets.quiet.pawn
 
 			index, tempPotential := 0, Bitboard(potential)
 			for tempPotential != 0 {
@@ -191,9 +305,9 @@ func GeneratePseudoMovesInternal(f func(move Move), g *GameState, onlyCaptures b
 		// generate captures
 		{
 			for _, captureOffset := range PawnCaptureOffsets[player] {
-				potential := playerBoards.Pieces[Pawn] & PremoveMaskFromOffset(captureOffset)
+				potential := pieces[Pawn] & PremoveMaskFromOffset(captureOffset)
 				potential = RotateTowardsIndex64(potential, captureOffset)
-				potential = potential & enemyBoards.Occupied
+				potential = potential & enemyBoards.Occupied & targets.captures
 
 				index, tempPotential := 0, Bitboard(potential)
 				for tempPotential != 0 {
@@ -207,9 +321,9 @@ func GeneratePseudoMovesInternal(f func(move Move), g *GameState, onlyCaptures b
 		// generate en-passant
 		{
 			if g.EnPassantTarget.HasValue() {
-				enPassantBoard := SingleBitboard(IndexFromFileRank(g.EnPassantTarget.Value()))
+				enPassantBoard := SingleBitboard(IndexFromFileRank(g.EnPassantTarget.Value())) & targets.captures
 				for _, captureOffset := range []int{pushOffset + OffsetE, pushOffset + OffsetW} {
-					potential := playerBoards.Pieces[Pawn] & PremoveMaskFromOffset(captureOffset)
+					potential := pieces[Pawn] & PremoveMaskFromOffset(captureOffset)
 					potential = RotateTowardsIndex64(potential, captureOffset)
 					potential = potential & enPassantBoard
 
@@ -245,18 +359,18 @@ func GeneratePseudoMovesInternal(f func(move Move), g *GameState, onlyCaptures b
 		// *moves = generateWalkMoves(playerBoards.pieces[QUEEN], b.occupied, enemyBoards.occupied, NW, *moves)
 		// *moves = generateWalkMoves(playerBoards.pieces[QUEEN], b.occupied, enemyBoards.occupied, SW, *moves)
 
-		generateWalkMovesWithMagic(f, playerBoards.Pieces[Rook], b.Occupied, playerBoards.Occupied, RookMagicTable, onlyCaptures)
-		generateWalkMovesWithMagic(f, playerBoards.Pieces[Bishop], b.Occupied, playerBoards.Occupied, BishopMagicTable, onlyCaptures)
-		generateWalkMovesWithMagic(f, playerBoards.Pieces[Queen], b.Occupied, playerBoards.Occupied, RookMagicTable, onlyCaptures)
-		generateWalkMovesWithMagic(f, playerBoards.Pieces[Queen], b.Occupied, playerBoards.Occupied, BishopMagicTable, onlyCaptures)
+		generateWalkMovesWithMagic(f, pieces[Rook], b.Occupied, playerBoards.Occupied, RookMagicTable, targets.quiet.rook, targets.captures)
+		generateWalkMovesWithMagic(f, pieces[Bishop], b.Occupied, playerBoards.Occupied, BishopMagicTable, targets.quiet.bishop, targets.captures)
+		generateWalkMovesWithMagic(f, pieces[Queen], b.Occupied, playerBoards.Occupied, RookMagicTable, targets.quiet.queen, targets.captures)
+		generateWalkMovesWithMagic(f, pieces[Queen], b.Occupied, playerBoards.Occupied, BishopMagicTable, targets.quiet.queen, targets.captures)
 	}
 
 	{
 		// generate knight moves
-		generateJumpMovesByLookup(f, playerBoards.Pieces[Knight], b.Occupied, playerBoards.Occupied, KnightAttackMasks, onlyCaptures)
+		generateJumpMovesByLookup(f, pieces[Knight], b.Occupied, playerBoards.Occupied, KnightAttackMasks, targets.quiet.knight, targets.captures)
 
 		// generate king moves
-		generateJumpMovesByLookup(f, playerBoards.Pieces[King], b.Occupied, playerBoards.Occupied, KingAttackMasks, onlyCaptures)
+		generateJumpMovesByLookup(f, pieces[King], b.Occupied, playerBoards.Occupied, KingAttackMasks, targets.quiet.king, targets.captures)
 	}
 }
 
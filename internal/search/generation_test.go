@@ -606,3 +606,87 @@ func TestPosition5QueenRetreat(t *testing.T) {
 	assert.True(t, Contains(moveStrings, "d4g7"))
 	assert.True(t, Contains(moveStrings, "d4g1"))
 }
+
+func TestGenerateCapturesPromotionsAndChecks(t *testing.T) {
+	type testCase struct {
+		fen      string
+		mode     MoveGenerationMode
+		expected []string
+	}
+
+	cases := []testCase{
+		{"4k3/1P6/8/3p4/8/5N2/6P1/R3K1B1 w Q - 0 1", OnlyCaptures, []string{}},
+		{"4k3/1P6/8/3p4/8/5N2/6P1/R3K1B1 w Q - 0 1", CapturesAndPromotions, []string{"b7b8q"}},
+		{"4k3/1P6/8/3p4/8/5N2/6P1/R3K1B1 w Q - 0 1", CapturesPromotionsAndChecks, []string{"b7b8q", "a1a8"}},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 2 3", CapturesPromotionsAndChecks, []string{"c4f7", "f3f7"}},
+		{"4k3/8/8/8/8/5p2/8/n3K3 b - - 0 1", CapturesAndPromotions, []string{}},
+		{"4k3/8/8/8/8/5p2/8/n3K3 b - - 0 1", CapturesPromotionsAndChecks, []string{"f3f2", "a1c2"}},
+	}
+
+	for _, c := range cases {
+		g, err := GamestateFromFenString(c.fen)
+		assert.True(t, IsNil(err), err)
+
+		moves := []string{}
+		GeneratePseudoMovesForMode(func(m Move) {
+			moves = append(moves, m.String())
+		}, g, c.mode)
+		assert.ElementsMatch(t, c.expected, moves, c.fen)
+	}
+}
+
+var _stagedGenerationFens = []string{
+	"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+	"8/8/8/2k5/3Pp3/8/8/4K3 b - d3 0 1",
+}
+
+func TestQuietMovesAndCapturesAndPromotionsMakeUpAllMoves(t *testing.T) {
+	for _, fen := range _stagedGenerationFens {
+		g, err := GamestateFromFenString(fen)
+		assert.True(t, IsNil(err), err)
+
+		generate := func(mode MoveGenerationMode) []string {
+			moves := []string{}
+			GeneratePseudoMovesForMode(func(m Move) {
+				moves = append(moves, m.String())
+			}, g, mode)
+			return moves
+		}
+
+		all := generate(AllMoves)
+		captures := generate(CapturesAndPromotions)
+		quiet := generate(QuietMoves)
+
+		assert.ElementsMatch(t, all, append(captures, quiet...), fen)
+	}
+}
+
+func TestIsPseudoLegal(t *testing.T) {
+	allMoves := []Move{}
+	movesByFen := map[string][]Move{}
+
+	for _, fen := range _stagedGenerationFens {
+		g, err := GamestateFromFenString(fen)
+		assert.True(t, IsNil(err), err)
+
+		GeneratePseudoMovesWithAllPromotions(func(m Move) {
+			movesByFen[fen] = append(movesByFen[fen], m)
+		}, g)
+		allMoves = append(allMoves, movesByFen[fen]...)
+	}
+
+	for _, fen := range _stagedGenerationFens {
+		g, err := GamestateFromFenString(fen)
+		assert.True(t, IsNil(err), err)
+
+		// Moves generated in the other positions are only pseudo legal here
+		// if they're generated here too
+		for _, move := range allMoves {
+			assert.Equal(t, Contains(movesByFen[fen], move), IsPseudoLegal(g, move), fen, move.String())
+		}
+	}
+}
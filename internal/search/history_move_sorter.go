@@ -0,0 +1,211 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+const (
+	_historyCaptureScore    = 3_000_000
+	_historyKillerScore     = 2_000_000
+	_historyCounterScore    = 2_000_000 - 1
+	_historyMaxScore        = 1_000_000
+	_historyNumKillersInPly = 2
+)
+
+// moveHistory is shared between a HistoryMoveSorter and its copies.
+type moveHistory struct {
+	game *game.GameState
+
+	// killers[ply] are quiet moves that caused a beta cutoff `ply` plies from
+	// the search root
+	killers [][_historyNumKillersInPly]Optional[Move]
+
+	// history[player][start][end] increases every time a quiet move causes a
+	// beta cutoff, weighted by the depth of the cutoff
+	history [2][64][64]int
+
+	// countermoves[start][end] is the quiet move that refuted the previous move
+	countermoves [64][64]Optional[Move]
+
+	// the moves leading to the current position, used to find the previous
+	// move. Null moves are empty moves.
+	pastMoves []Move
+}
+
+// HistoryMoveSorter orders moves using killer moves, a butterfly history table
+// and countermove replies. These are learned from beta cutoffs in alphaBeta.
+//
+// Winning captures and promotions come first, followed by killers, the
+// countermove, the rest of the quiet moves ordered by history and finally
+// captures that lose material. The wrapped MoveSorter
+// is applied afterwards so its moves (eg principal variations from the
+// VariationMovePrioritizer) are tried before everything else.
+type HistoryMoveSorter struct {
+	*moveHistory
+	next MoveSorter
+
+	noCopy NoCopy
+}
+
+var _ game.MoveListener = (*HistoryMoveSorter)(nil)
+var _ MoveSorter = (*HistoryMoveSorter)(nil)
+
+func NewHistoryMoveSorter(
+	g *game.GameState,
+	next MoveSorter,
+) (func(), *HistoryMoveSorter) {
+	sorter := &HistoryMoveSorter{
+		moveHistory: &moveHistory{game: g},
+		next:        next,
+	}
+
+	unregister := g.RegisterListener(sorter)
+	return unregister, sorter
+}
+
+// CreateHistoryMoveSorter combines the history heuristics with the variation
+// ordering that the search uses by default.
+var CreateHistoryMoveSorter MoveSorterConstructor = func(g *game.GameState) (func(), MoveSorter) {
+	unregisterVariations, variations := NewVariationMovePrioritizer(g)
+	unregisterHistory, sorter := NewHistoryMoveSorter(g, variations)
+
+	return func() {
+		unregisterHistory()
+		unregisterVariations()
+	}, sorter
+}
+
+func (s *HistoryMoveSorter) copy() MoveSorter {
+	return &HistoryMoveSorter{
+		moveHistory: s.moveHistory,
+		next:        s.next.copy(),
+	}
+}
+
+func (s *HistoryMoveSorter) reset(variations []Pair[int, []SearchMove]) {
+	s.next.reset(variations)
+}
+
+func (s *HistoryMoveSorter) AfterMove(move Move, update *BoardUpdate) {
+	s.pastMoves = append(s.pastMoves, move)
+}
+
+func (s *HistoryMoveSorter) AfterUndo(update *BoardUpdate) {
+	_, s.pastMoves = PopValue(s.pastMoves, Move{})
+}
+
+func (s *HistoryMoveSorter) String() string {
+	return fmt.Sprintf("HistoryMoveSorter[previous %v, countermove %v]", s.previousMove(), s.countermove())
+}
+
+func (s *moveHistory) previousMove() Optional[Move] {
+	if len(s.pastMoves) == 0 || s.pastMoves[len(s.pastMoves)-1] == (Move{}) {
+		return Empty[Move]()
+	}
+	return Some(s.pastMoves[len(s.pastMoves)-1])
+}
+
+func (s *moveHistory) killersAtPly(ply int) [_historyNumKillersInPly]Optional[Move] {
+	if ply < len(s.killers) {
+		return s.killers[ply]
+	}
+	return [_historyNumKillersInPly]Optional[Move]{}
+}
+
+func (s *moveHistory) countermove() Optional[Move] {
+	previous := s.previousMove()
+	if previous.IsEmpty() {
+		return Empty[Move]()
+	}
+	return s.countermoves[previous.Value().StartIndex][previous.Value().EndIndex]
+}
+
+func isQuietMove(move Move) bool {
+	return !move.MoveType.Captures() && move.PromotionPiece.IsEmpty()
+}
+
+func (s *moveHistory) score(move Move, killers [_historyNumKillersInPly]Optional[Move], countermove Optional[Move]) int {
+	if !isQuietMove(move) {
+		order := captureOrder(s.game, move)
+		if order < 0 {
+			// Losing captures are tried after the quiet moves
+			return order
+		}
+		return _historyCaptureScore + order
+	}
+	for i, killer := range killers {
+		if killer == Some(move) {
+			return _historyKillerScore + _historyNumKillersInPly - i
+		}
+	}
+	if countermove == Some(move) {
+		return _historyCounterScore
+	}
+	return s.history[s.game.Player][move.StartIndex][move.EndIndex]
+}
+
+func (s *HistoryMoveSorter) sortMoves(currentDepth int, moves *[]Move) Error {
+	killers := s.killersAtPly(currentDepth)
+	countermove := s.countermove()
+
+	sortMovesMaxFirst(moves, func(move Move) int {
+		return s.score(move, killers, countermove)
+	})
+
+	// The wrapped sorter is stable, so moves it doesn't prioritize stay in
+	// history order
+	return s.next.sortMoves(currentDepth, moves)
+}
+
+func (s *HistoryMoveSorter) principalMoves(output *[]Move) {
+	s.next.principalMoves(output)
+}
+
+// killerMoves are the killer moves at this ply followed by the countermove
+func (s *HistoryMoveSorter) killerMoves(currentDepth int, output *[]Move) {
+	for _, killer := range s.killersAtPly(currentDepth) {
+		if killer.HasValue() {
+			*output = append(*output, killer.Value())
+		}
+	}
+	if countermove := s.countermove(); countermove.HasValue() {
+		*output = append(*output, countermove.Value())
+	}
+	s.next.killerMoves(currentDepth, output)
+}
+
+func (s *HistoryMoveSorter) recordCutoff(move Move, currentDepth int, depthRemaining int) {
+	if !isQuietMove(move) {
+		return
+	}
+
+	for len(s.killers) <= currentDepth {
+		s.killers = append(s.killers, [_historyNumKillersInPly]Optional[Move]{})
+	}
+	killers := &s.killers[currentDepth]
+	if killers[0] != Some(move) {
+		copy(killers[1:], killers[:_historyNumKillersInPly-1])
+		killers[0] = Some(move)
+	}
+
+	previous := s.previousMove()
+	if previous.HasValue() {
+		s.countermoves[previous.Value().StartIndex][previous.Value().EndIndex] = Some(move)
+	}
+
+	history := &s.history[s.game.Player]
+	history[move.StartIndex][move.EndIndex] += depthRemaining * depthRemaining
+	if history[move.StartIndex][move.EndIndex] > _historyMaxScore {
+		// Age the table so recent cutoffs matter more than old ones
+		for start := range history {
+			for end := range history[start] {
+				history[start][end] /= 2
+			}
+		}
+	}
+
+	s.next.recordCutoff(move, currentDepth, depthRemaining)
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryMoveSorterOrdering(t *testing.T) {
+	g, err := game.GamestateFromFenString("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	assert.True(t, IsNil(err), err)
+
+	unregister, sorter := NewHistoryMoveSorter(g, &NoOpMoveSorter{})
+	defer unregister()
+
+	killer := MoveFromString("f1c4", QuietMove)
+	historyMove := MoveFromString("d2d3", QuietMove)
+	losingCapture := MoveFromString("f3e5", CaptureMove)
+
+	sorter.recordCutoff(historyMove, 0, 3)
+	sorter.recordCutoff(historyMove, 0, 3)
+	sorter.recordCutoff(killer, 0, 1)
+	sorter.recordCutoff(losingCapture, 0, 4)
+
+	moves := []Move{
+		MoveFromString("a2a3", QuietMove),
+		losingCapture,
+		historyMove,
+		MoveFromString("h2h3", QuietMove),
+		killer,
+	}
+	err = sorter.sortMoves(0, &moves)
+	assert.True(t, IsNil(err), err)
+
+	// the knight on e5 would be recaptured
+	assert.Equal(t, "f1c4, d2d3, a2a3, h2h3, f3e5", ConcatStringify(moves))
+}
+
+func TestHistoryMoveSorterKillersAreIndexedFromTheRoot(t *testing.T) {
+	g, err := game.GamestateFromFenString("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	assert.True(t, IsNil(err), err)
+
+	unregister, sorter := NewHistoryMoveSorter(g, &NoOpMoveSorter{})
+	defer unregister()
+
+	sorter.recordCutoff(MoveFromString("f1c4", QuietMove), 2, 1)
+
+	killers := []Move{}
+	sorter.killerMoves(0, &killers)
+	assert.Empty(t, killers)
+
+	// Moves played before the search starts don't shift the killers
+	update := BoardUpdate{}
+	err = g.PerformMove(MoveFromString("h2h3", QuietMove), &update)
+	assert.True(t, IsNil(err), err)
+	defer g.UndoUpdate(&update)
+
+	killers = []Move{}
+	sorter.killerMoves(2, &killers)
+	assert.Equal(t, "f1c4", ConcatStringify(killers))
+}
+
+func TestHistoryMoveSorterCombinesWithVariations(t *testing.T) {
+	g, err := game.GamestateFromFenString("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	assert.True(t, IsNil(err), err)
+
+	unregister, sorter := CreateHistoryMoveSorter(g)
+	defer unregister()
+
+	sorter.reset([]Pair[int, []SearchMove]{
+		{First: 10, Second: []SearchMove{{MoveFromString("a2a3", QuietMove), false}}},
+	})
+	sorter.recordCutoff(MoveFromString("f1c4", QuietMove), 0, 1)
+
+	moves := []Move{
+		MoveFromString("h2h3", QuietMove),
+		MoveFromString("f1c4", QuietMove),
+		MoveFromString("a2a3", QuietMove),
+	}
+	err = sorter.sortMoves(0, &moves)
+	assert.True(t, IsNil(err), err)
+
+	assert.Equal(t, "a2a3, f1c4, h2h3", ConcatStringify(moves))
+}
+
+func TestHistoryMoveSorterSearchesFewerMoves(t *testing.T) {
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	with := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(6), CreateMoveSorter: Some(CreateHistoryMoveSorter)})
+	without := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(6)})
+
+	fmt.Println("with history", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+}
+
+type nullMoveCounter struct {
+	nullMoves int
+}
+
+func (c *nullMoveCounter) AfterMove(move Move, update *BoardUpdate) {
+	if move == (Move{}) {
+		c.nullMoves++
+	}
+}
+
+func (c *nullMoveCounter) AfterUndo(update *BoardUpdate) {
+}
+
+func TestHistoryMoveSorterFollowsNullMoves(t *testing.T) {
+	g, err := game.GamestateFromFenString("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+	assert.True(t, IsNil(err), err)
+
+	unregister, sorter := NewHistoryMoveSorter(g, &NoOpMoveSorter{})
+	defer unregister()
+
+	update := BoardUpdate{}
+	err = g.PerformMove(MoveFromString("f1c4", QuietMove), &update)
+	assert.True(t, IsNil(err), err)
+
+	nullUpdate := BoardUpdate{}
+	err = g.PerformNullMove(&nullUpdate)
+	assert.True(t, IsNil(err), err)
+
+	// The cutoff after the null move isn't a reply to f1c4
+	assert.True(t, sorter.previousMove().IsEmpty())
+	sorter.recordCutoff(MoveFromString("d2d3", QuietMove), 2, 1)
+
+	err = g.UndoNullMove(&nullUpdate)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, Some(MoveFromString("f1c4", QuietMove)), sorter.previousMove())
+	assert.True(t, sorter.countermove().IsEmpty())
+
+	err = g.UndoUpdate(&update)
+	assert.True(t, IsNil(err), err)
+	assert.Empty(t, sorter.pastMoves)
+
+	// Null moves during a search are undone as well
+	unregisterHelper, helper := NewSearchHelper(g, SearchOptions{MaxDepth: Some(5), CreateMoveSorter: Some(CreateHistoryMoveSorter)})
+	defer unregisterHelper()
+
+	counter := &nullMoveCounter{}
+	defer g.RegisterListener(counter)()
+
+	_, err = helper.Search()
+	assert.True(t, IsNil(err), err)
+
+	assert.Greater(t, counter.nullMoves, 0)
+	assert.Empty(t, helper.MoveSorter.(*HistoryMoveSorter).pastMoves)
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// IncrementalEvaluator keeps each player's material and piece-square sums up
+// to date as moves are made and undone, so that evaluating doesn't recount
+// every piece. The pawn center and endgame terms depend on more than single
+// squares and are still computed from the bitboards.
+type IncrementalEvaluator struct {
+	g *game.GameState
+
+	pieces      [2]int
+	development [2]int
+
+	// Checks each evaluation against Evaluate
+	debug bool
+
+	noCopy NoCopy
+}
+
+var _ game.MoveListener = (*IncrementalEvaluator)(nil)
+
+func NewIncrementalEvaluator(g *game.GameState, debug bool) (func(), *IncrementalEvaluator) {
+	e := &IncrementalEvaluator{g: g, debug: debug}
+	for i, piece := range g.Board {
+		e.add(piece, i, 1)
+	}
+
+	unregister := g.RegisterListener(e)
+	return unregister, e
+}
+
+func (e *IncrementalEvaluator) add(piece Piece, index int, sign int) {
+	if piece == XX {
+		return
+	}
+	player := piece.Player()
+	pieceType := piece.PieceType()
+	e.pieces[player] += sign * _pieceValues[pieceType]
+	e.development[player] += sign * _developmentSquareValues[player][pieceType][index]
+}
+
+func (e *IncrementalEvaluator) AfterMove(move Move, update *BoardUpdate) {
+	for i := 0; i < update.Num; i++ {
+		e.add(update.PrevPieces[i], update.Indices[i], -1)
+		e.add(update.Pieces[i], update.Indices[i], 1)
+	}
+}
+
+func (e *IncrementalEvaluator) AfterUndo(update *BoardUpdate) {
+	for i := update.Num - 1; i >= 0; i-- {
+		e.add(update.Pieces[i], update.Indices[i], -1)
+		e.add(update.PrevPieces[i], update.Indices[i], 1)
+	}
+}
+
+// Evaluate matches Evaluate for the current position
+func (e *IncrementalEvaluator) Evaluate(player Player) (int, Error) {
+	b := e.g.Bitboards
+	enemy := player.Other()
+
+	result := e.pieces[player] - e.pieces[enemy] +
+		e.development[player] + evaluatePawnCenter(b, player) -
+		e.development[enemy] - evaluatePawnCenter(b, enemy) +
+		evaluateEndgame(b, enemy, e.pieces[enemy])
+
+	if e.debug {
+		if expected := Evaluate(b, player); result != expected {
+			return result, Errorf("incremental evaluation %v doesn't match %v for %v", result, expected, game.FenStringForGame(e.g))
+		}
+	}
+
+	return result, NilError
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalEvaluationMatchesEvaluate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		// Promotions
+		"n1n5/PPPk4/8/8/8/8/4Kppp/5N1N b - - 0 1",
+		// En passant
+		"8/8/8/2k5/3Pp3/8/8/4K3 b - d3 0 1",
+	} {
+		g := UnwrapReturn(GamestateFromFenString(fen))
+		unregister, e := NewIncrementalEvaluator(g, true)
+
+		// Play random games, undoing some of the moves along the way
+		updates := []*BoardUpdate{}
+		for i := 0; i < 200; i++ {
+			for _, player := range []Player{White, Black} {
+				score, err := e.Evaluate(player)
+				assert.True(t, IsNil(err), err)
+				assert.Equal(t, Evaluate(g.Bitboards, player), score)
+			}
+
+			moves := []Move{}
+			err := GenerateLegalMoves(g, &moves)
+			assert.True(t, IsNil(err), err)
+
+			if len(updates) > 0 && (len(moves) == 0 || r.Intn(3) == 0) {
+				err = g.UndoUpdate(updates[len(updates)-1])
+				assert.True(t, IsNil(err), err)
+				updates = updates[:len(updates)-1]
+				continue
+			}
+			if len(moves) == 0 {
+				break
+			}
+
+			update := &BoardUpdate{}
+			err = g.PerformMove(moves[r.Intn(len(moves))], update)
+			assert.True(t, IsNil(err), err)
+			updates = append(updates, update)
+		}
+
+		unregister()
+	}
+}
+
+func TestIncrementalEvaluationInSearch(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	// The debug logger checks every evaluation against Evaluate
+	pv, score, err := Search(fen, SearchOptions{MaxDepth: Some(3), DebugLogger: Some[Logger](&SilentLogger)})
+	assert.True(t, IsNil(err), err)
+
+	expectedPv, expectedScore, err := Search(fen, SearchOptions{MaxDepth: Some(3), WithoutIncrementalEvaluation: true})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, expectedScore, score)
+	assert.Equal(t, expectedPv, pv)
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"sync"
+	"sync/atomic"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// startWorkers launches the extra threads for a lazy SMP search. Each worker
+// searches the same root position on its own copy of the game and shares
+// results with every other thread through the transposition table. The
+// returned function stops the workers and waits for them to exit.
+//
+// Workers race each other through the transposition table, so none are
+// started for a node-limited search, including weakened ones.
+func (helper *SearchHelper) startWorkers() func() Error {
+	numWorkers := helper.Threads.ValueOr(1) - 1
+	if numWorkers <= 0 || helper.TranspositionTable == nil || helper.maxNodes().HasValue() {
+		return func() Error { return NilError }
+	}
+
+	stopped := &atomic.Bool{}
+	wg := sync.WaitGroup{}
+
+	errs := make([]Error, numWorkers)
+	cleanups := []func(){}
+
+	for i := 0; i < numWorkers; i++ {
+		options := helper.SearchOptions
+		options.Threads = Empty[int]()
+		options.Logger = Some[Logger](&SilentLogger)
+		options.DebugLogger = Empty[Logger]()
+		options.TreeRecorder = Empty[*SearchTreeRecorder]()
+		options.OnIteration = Empty[func(SearchResult)]()
+
+		cleanup, worker := newSearchHelper(helper.GameState.Clone(), options, helper.TranspositionTable)
+		worker.stopped = stopped
+		cleanups = append(cleanups, cleanup)
+
+		wg.Add(1)
+		go func(i int, worker *SearchHelper) {
+			defer wg.Done()
+
+			// Half of the workers start one ply deeper so that the threads
+			// don't all search the same tree in lock-step
+			_, _, errs[i] = worker.iterativeDeepening((i + 1) % 2)
+		}(i, worker)
+	}
+
+	return func() Error {
+		stopped.Store(true)
+		wg.Wait()
+
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+
+		return Join(errs...)
+	}
+}
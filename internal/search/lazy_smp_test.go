@@ -0,0 +1,35 @@
+package search
+
+import (
+	"testing"
+
+	. "github.com/cricklet/chessgo/internal/game"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazySMPFindsMate(t *testing.T) {
+	fen := "5b2/3kp2p/4r3/1p6/4n3/p3P1p1/3p1r2/6K1 b - - 1 46"
+
+	result, score, err := Search(fen, SearchOptions{MaxDepth: Some(5), Threads: Some(4), CreateEvaluator: Some(CreateBasicEvaluator)})
+	assert.True(t, IsNil(err), err)
+
+	assert.Equal(t, "mate+1", ScoreString(score))
+	assert.Equal(t, "d2d1q", result[0].String())
+}
+
+func TestLazySMPWorkersDontTouchGameState(t *testing.T) {
+	fen := "r3k2r/1bq1bppp/pp2p3/2p1n3/P3PP2/2PBN3/1P1BQ1PP/R4RK1 b kq - 0 16"
+
+	g, err := GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{MaxDepth: Some(3), Threads: Some(3)})
+	defer unregister()
+
+	_, err = helper.Search()
+	assert.True(t, IsNil(err), err)
+
+	assert.Equal(t, fen, FenStringForGame(g))
+}
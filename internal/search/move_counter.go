@@ -25,11 +25,15 @@ func (gen *MoveCounter) Reset() {
 	gen.movesSearched = 0
 }
 
-func (gen *MoveCounter) AfterMove(move Move) {
+func (gen *MoveCounter) AfterMove(move Move, update *BoardUpdate) {
+	if move == (Move{}) {
+		// Null moves aren't counted
+		return
+	}
 	gen.movesSearched++
 }
 
-func (gen *MoveCounter) AfterUndo() {
+func (gen *MoveCounter) AfterUndo(update *BoardUpdate) {
 }
 
 func (gen *MoveCounter) NumMoves() int {
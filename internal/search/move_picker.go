@@ -0,0 +1,209 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// moveStage is how far a MovePicker has got, in the order moves are tried
+type moveStage int
+
+const (
+	hashMoveStage moveStage = iota
+	principalMovesStage
+	winningCapturesStage
+	killerMovesStage
+	quietMovesStage
+	losingCapturesStage
+	doneStage
+
+	// With WithoutStagedMoveGeneration, every move is generated and sorted
+	// before the first one is tried
+	unstagedStage
+	allMovesStage
+)
+
+// MovePicker yields the moves at a node in stages: the hash move, the
+// principal moves of the MoveSorter, winning captures, killers, the rest of
+// the quiet moves ordered by the MoveSorter and finally the captures that
+// lose material. Moves are only generated once the stages before them are
+// exhausted, so nodes that cut off early don't generate or sort moves that
+// are never tried.
+//
+// Each ply reuses its picker and move buffers, see plyState.
+type MovePicker struct {
+	helper *SearchHelper
+	ply    int
+	stage  moveStage
+
+	hashMove Optional[Move]
+
+	// The moves of the current stage
+	current []Move
+	index   int
+
+	// Filled by the generated stages. Losing captures are the end of
+	// `captures`.
+	captures       []Move
+	quiets         []Move
+	losingCaptures []Move
+
+	// Moves that were tried before their stage was generated. They're skipped
+	// when it is.
+	tried []Move
+
+	// Filled by the MoveSorter for the principal and killer stages
+	sorterMoves []Move
+
+	// Whether the MoveGen left out any moves. Only valid once every move has
+	// been picked.
+	result MoveGenerationResult
+}
+
+// movePicker resets the picker for the node at `ply`
+func (helper *SearchHelper) movePicker(ply int, hashMove Optional[Move]) *MovePicker {
+	p := &helper.ply(ply).picker
+	*p = MovePicker{
+		helper:      helper,
+		ply:         ply,
+		hashMove:    hashMove,
+		captures:    p.captures,
+		quiets:      p.quiets,
+		tried:       p.tried[:0],
+		sorterMoves: p.sorterMoves[:0],
+		result:      AllLegalMoves,
+	}
+
+	if helper.WithoutStagedMoveGeneration {
+		p.stage = unstagedStage
+	} else if hashMove.HasValue() {
+		p.current = append(p.sorterMoves, hashMove.Value())
+	}
+
+	return p
+}
+
+// next returns the next move to search, or nothing once every move has been
+// picked
+func (p *MovePicker) next() (Optional[Move], Error) {
+	for p.stage != doneStage {
+		if p.index < len(p.current) {
+			move := p.current[p.index]
+			p.index++
+			if p.accept(move) {
+				return Some(move), NilError
+			}
+			continue
+		}
+
+		err := p.nextStage()
+		if err.HasError() {
+			return Empty[Move](), err
+		}
+	}
+
+	return Empty[Move](), NilError
+}
+
+// accept checks that moves which weren't generated are valid and that
+// generated moves haven't been tried already
+func (p *MovePicker) accept(move Move) bool {
+	switch p.stage {
+	case hashMoveStage, principalMovesStage, killerMovesStage:
+		if Contains(p.tried, move) || !p.helper.MoveGen.isPseudoLegal(p.helper.GameState, move) {
+			return false
+		}
+		if p.stage == killerMovesStage && !isQuietMove(move) {
+			return false
+		}
+		p.tried = append(p.tried, move)
+		return true
+	}
+	return !Contains(p.tried, move)
+}
+
+func (p *MovePicker) nextStage() Error {
+	p.index = 0
+	p.current = nil
+
+	switch p.stage {
+	case unstagedStage:
+		p.stage = allMovesStage
+		err := p.generate(AllMoves, &p.quiets)
+		if err.HasError() {
+			return err
+		}
+		sortMovesMaxFirst(&p.quiets, func(move Move) int {
+			return captureOrder(p.helper.GameState, move)
+		})
+		err = p.helper.MoveSorter.sortMoves(p.ply, &p.quiets)
+		if err.HasError() {
+			return err
+		}
+		if p.hashMove.HasValue() {
+			MoveToFront(&p.quiets, func(m Move) bool {
+				return m == p.hashMove.Value()
+			})
+		}
+		p.current = p.quiets
+
+	case hashMoveStage:
+		p.stage = principalMovesStage
+		p.sorterMoves = p.sorterMoves[:0]
+		p.helper.MoveSorter.principalMoves(&p.sorterMoves)
+		p.current = p.sorterMoves
+
+	case principalMovesStage:
+		p.stage = winningCapturesStage
+		err := p.generate(CapturesAndPromotions, &p.captures)
+		if err.HasError() {
+			return err
+		}
+
+		numLosing := 0
+		sortMovesMaxFirst(&p.captures, func(move Move) int {
+			order := captureOrder(p.helper.GameState, move)
+			if order < 0 {
+				numLosing++
+			}
+			return order
+		})
+
+		p.current = p.captures[:len(p.captures)-numLosing]
+		p.losingCaptures = p.captures[len(p.captures)-numLosing:]
+
+	case winningCapturesStage:
+		p.stage = killerMovesStage
+		p.sorterMoves = p.sorterMoves[:0]
+		p.helper.MoveSorter.killerMoves(p.ply, &p.sorterMoves)
+		p.current = p.sorterMoves
+
+	case killerMovesStage:
+		p.stage = quietMovesStage
+		err := p.generate(QuietMoves, &p.quiets)
+		if err.HasError() {
+			return err
+		}
+		err = p.helper.MoveSorter.sortMoves(p.ply, &p.quiets)
+		if err.HasError() {
+			return err
+		}
+		p.current = p.quiets
+
+	case quietMovesStage:
+		p.stage = losingCapturesStage
+		p.current = p.losingCaptures
+
+	case losingCapturesStage, allMovesStage:
+		p.stage = doneStage
+	}
+
+	return NilError
+}
+
+func (p *MovePicker) generate(mode MoveGenerationMode, moves *[]Move) Error {
+	result, err := p.helper.MoveGen.generateMoves(p.helper.GameState, mode, moves)
+	if result == SomeLegalMoves {
+		p.result = SomeLegalMoves
+	}
+	return err
+}
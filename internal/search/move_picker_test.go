@@ -0,0 +1,144 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func pickAllMoves(t *testing.T, helper *SearchHelper, hashMove Optional[Move]) ([]string, MoveGenerationResult) {
+	picker := helper.movePicker(0, hashMove)
+
+	moves := []string{}
+	for {
+		next, err := picker.next()
+		assert.True(t, IsNil(err), err)
+		if next.IsEmpty() {
+			break
+		}
+		moves = append(moves, next.Value().String())
+	}
+
+	return moves, picker.result
+}
+
+func TestMovePickerStages(t *testing.T) {
+	// The knight on h4 hangs, the pawn on e5 is defended twice
+	g, err := game.GamestateFromFenString("r1bqkb1r/pppp2pp/2n2p2/4p3/4P2n/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 4")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{})
+	defer unregister()
+
+	unregisterSorter, sorter := NewHistoryMoveSorter(g, &NoOpMoveSorter{})
+	defer unregisterSorter()
+	helper.MoveSorter = sorter
+
+	sorter.recordCutoff(MoveFromString("f1c4", QuietMove), 0, 1)
+
+	expected := []string{}
+	GeneratePseudoMoves(func(m Move) {
+		expected = append(expected, m.String())
+	}, g)
+
+	moves, result := pickAllMoves(t, helper, Some(MoveFromString("d2d3", QuietMove)))
+	assert.Equal(t, AllLegalMoves, result)
+	assert.ElementsMatch(t, expected, moves)
+
+	assert.Equal(t, "d2d3", moves[0])
+	assert.Equal(t, "f3h4", moves[1])
+	assert.Equal(t, "f1c4", moves[2])
+	assert.Equal(t, "f3e5", moves[len(moves)-1])
+
+	// Hash moves that aren't valid in this position are skipped
+	moves, _ = pickAllMoves(t, helper, Some(MoveFromString("e7e5", QuietMove)))
+	assert.ElementsMatch(t, expected, moves)
+	assert.Equal(t, "f3h4", moves[0])
+
+	// Without staging, every move is generated and sorted in the same order
+	helper.WithoutStagedMoveGeneration = true
+	unstaged, _ := pickAllMoves(t, helper, Some(MoveFromString("d2d3", QuietMove)))
+	assert.ElementsMatch(t, expected, unstaged)
+	assert.Equal(t, "d2d3", unstaged[0])
+	assert.Equal(t, "f3e5", unstaged[len(unstaged)-1])
+}
+
+func TestMovePickerWithSearchTree(t *testing.T) {
+	g, err := game.GamestateFromFenString("r1bqkb1r/pppp2pp/2n2p2/4p3/4P2n/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 4")
+	assert.True(t, IsNil(err), err)
+
+	tree, err := SearchTreeFromLines([][]string{{"a2a3"}, {"f3e5"}}, true)
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{CreateMoveGen: Some(CreateSearchTreeMoveGenerator(tree))})
+	defer unregister()
+
+	// The hash move isn't in the tree
+	moves, result := pickAllMoves(t, helper, Some(MoveFromString("f3h4", CaptureMove)))
+	assert.Equal(t, SomeLegalMoves, result)
+	assert.Equal(t, []string{"a2a3", "f3e5"}, moves)
+}
+
+// countingMoveGen counts the moves produced by the default generator
+type countingMoveGen struct {
+	DefaultMoveGenerator
+	generated int
+}
+
+func (gen *countingMoveGen) generateMoves(g *game.GameState, mode MoveGenerationMode, moves *[]Move) (MoveGenerationResult, Error) {
+	result, err := gen.DefaultMoveGenerator.generateMoves(g, mode, moves)
+	gen.generated += len(*moves)
+	return result, err
+}
+
+// generatedMovesPerNode searches `fen` and returns how many moves were
+// generated for each node visited
+func generatedMovesPerNode(t *testing.T, fen string, options SearchOptions) float64 {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	gen := &countingMoveGen{}
+	options.CreateMoveGen = Some[MoveGenConstructor](func(*game.GameState) (func(), MoveGen) {
+		return func() {}, gen
+	})
+
+	unregister, helper := NewSearchHelper(g, options)
+	defer unregister()
+
+	result, err := helper.Search()
+	assert.True(t, IsNil(err), err)
+
+	return float64(gen.generated) / float64(result.Nodes)
+}
+
+func TestStagedMoveGenerationGeneratesFewerMoves(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches to depth 6")
+	}
+
+	staged := generatedMovesPerNode(t, _middleGameFen, SearchOptions{MaxDepth: Some(6)})
+	unstaged := generatedMovesPerNode(t, _middleGameFen, SearchOptions{MaxDepth: Some(6), WithoutStagedMoveGeneration: true})
+
+	fmt.Printf("%.1f moves generated per node staged, %.1f unstaged\n", staged, unstaged)
+	assert.Less(t, staged, unstaged)
+}
+
+func benchmarkSearch(b *testing.B, fen string, options SearchOptions) {
+	for i := 0; i < b.N; i++ {
+		_, _, err := Search(fen, options)
+		if !IsNil(err) {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStagedMoveGeneration(b *testing.B) {
+	benchmarkSearch(b, _middleGameFen, SearchOptions{MaxDepth: Some(6)})
+}
+
+func BenchmarkUnstagedMoveGeneration(b *testing.B) {
+	benchmarkSearch(b, _middleGameFen, SearchOptions{MaxDepth: Some(6), WithoutStagedMoveGeneration: true})
+}
@@ -0,0 +1,41 @@
+package search
+
+import (
+	"sort"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// rootAlpha returns the score a root move has to beat to be one of the best
+// MultiPV lines. Until there are enough lines, every move has to beat the
+// original alpha.
+func (helper *SearchHelper) rootAlpha(alpha int, variations []Pair[int, []SearchMove]) int {
+	numLines := helper.numLines()
+	if len(variations) < numLines {
+		return alpha
+	}
+
+	scores := MapSlice(variations, func(v Pair[int, []SearchMove]) int {
+		return v.First
+	})
+	sort.Sort(sort.Reverse(sort.IntSlice(scores)))
+
+	worst := scores[numLines-1]
+	if numLines > 1 {
+		// Moves which tie the worst line need exact scores to be ranked
+		// against it
+		worst--
+	}
+
+	return MaxInt(alpha, worst)
+}
+
+// numLines is the number of root lines that get exact scores. A weakened
+// search needs a few lines to choose from, see pickSkillLine.
+func (helper *SearchHelper) numLines() int {
+	numLines := helper.MultiPV.ValueOr(1)
+	if helper.skill().HasValue() {
+		numLines = MaxInt(numLines, skillMultiPV)
+	}
+	return numLines
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func searchMultiPV(t *testing.T, fen string, options SearchOptions) []Pair[int, []Move] {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, options)
+	defer unregister()
+
+	result, err := helper.SearchMultiPV(context.Background())
+	assert.True(t, IsNil(err), err)
+	return result.Lines
+}
+
+func TestMultiPVScoresAreExact(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	// Without pruning that depends on the search window, the score for each
+	// move is exact when every move is a line
+	options := SearchOptions{
+		MaxDepth:                  Some(3),
+		WithoutTranspositionTable: true,
+		WithoutNullMovePruning:    true,
+		WithoutLateMoveReductions: true,
+	}
+
+	options.MultiPV = Some(100)
+	allLines := searchMultiPV(t, fen, options)
+	assert.Greater(t, len(allLines), 30)
+
+	expectedScores := map[string]int{}
+	for _, line := range allLines {
+		expectedScores[line.Second[0].String()] = line.First
+	}
+
+	options.MultiPV = Some(4)
+	lines := searchMultiPV(t, fen, options)
+	assert.Equal(t, 4, len(lines))
+
+	for i, line := range lines {
+		assert.Equal(t, allLines[i].First, line.First, i)
+		assert.Equal(t, expectedScores[line.Second[0].String()], line.First, line.Second[0].String())
+	}
+
+	options.MultiPV = Empty[int]()
+	pv, score, err := Search(fen, options)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, lines[0].First, score)
+	assert.Equal(t, lines[0].Second[0], pv[0])
+}
+
+func TestMultiPVReturnsDistinctMoves(t *testing.T) {
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	lines := searchMultiPV(t, fen, SearchOptions{MaxDepth: Some(4), MultiPV: Some(5)})
+	assert.Equal(t, 5, len(lines))
+
+	seen := map[Move]bool{}
+	for i, line := range lines {
+		assert.False(t, seen[line.Second[0]], line.Second[0].String())
+		seen[line.Second[0]] = true
+
+		if i > 0 {
+			assert.LessOrEqual(t, line.First, lines[i-1].First)
+		}
+	}
+}
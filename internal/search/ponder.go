@@ -0,0 +1,40 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// PonderMove guesses the opponent's reply to the first move in `pv` so that
+// we can think about it on their time. The principal variation usually has it,
+// but lines which were cut short by the transposition table fall back on the
+// cached best move.
+func (helper *SearchHelper) PonderMove(pv []Move) (Optional[Move], Error) {
+	if len(pv) == 0 {
+		return Empty[Move](), NilError
+	}
+	if len(pv) > 1 {
+		return Some(pv[1]), NilError
+	}
+	if helper.TranspositionTable == nil {
+		return Empty[Move](), NilError
+	}
+
+	undo, legal, err := performMoveAndReturnLegality(helper.GameState, pv[0])
+	if err.HasError() || !legal {
+		return Empty[Move](), Join(err, undo())
+	}
+
+	result := Empty[Move]()
+
+	cached := helper.TranspositionTable.load(helper.GameState.ZobristHash())
+	if cached.HasValue() && cached.Value().BestMove.HasValue() {
+		// Hash collisions can leave illegal moves in the table
+		legalMoves := []Move{}
+		err = GenerateLegalMoves(helper.GameState, &legalMoves)
+		if Contains(legalMoves, cached.Value().BestMove.Value()) {
+			result = cached.Value().BestMove
+		}
+	}
+
+	return result, Join(err, undo())
+}
@@ -0,0 +1,120 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// aspirationWindow is how far from the previous iteration's score the next
+// iteration's window starts. It grows by aspirationWindowGrowth on each
+// re-search until it falls back to the full window.
+const (
+	aspirationWindow        = 50
+	aspirationWindowGrowth  = 4
+	aspirationWindowMaximum = 1000
+)
+
+// searchMove searches the move that was just applied and returns its score
+// for the player who made it.
+//
+// The first move at each node is expected to be the principal variation and is
+// searched with the full window. Later moves are searched with a null window
+// (alpha, alpha+1) which is enough to prove that they're no better than the
+// principal variation. Only moves that beat alpha are re-searched with the full
+// window. Late move reductions are applied to the null-window search.
+func (helper *SearchHelper) searchMove(alpha int, beta int, currentDepth int, depthRemaining int, reduction int, firstMove bool, past []SearchMove) ([]SearchMove, int, Error) {
+	fullWindow := func() ([]SearchMove, int, Error) {
+		future, enemyScore, err := helper.alphaBeta(-beta, -alpha, currentDepth, depthRemaining, past)
+		return future, -enemyScore, err
+	}
+	nullWindow := func(depth int) ([]SearchMove, int, Error) {
+		future, enemyScore, err := helper.alphaBeta(-alpha-1, -alpha, currentDepth, depth, past)
+		return future, -enemyScore, err
+	}
+
+	if firstMove || (helper.WithoutPrincipalVariationSearch && reduction == 0) {
+		return fullWindow()
+	}
+
+	scoutDepth := depthRemaining - reduction
+	future, score, err := nullWindow(scoutDepth)
+	if err.HasError() {
+		return nil, score, err
+	}
+
+	if score > alpha && scoutDepth < depthRemaining && !helper.WithoutPrincipalVariationSearch {
+		// The reduced search beat alpha, check again at full depth
+		scoutDepth = depthRemaining
+		future, score, err = nullWindow(scoutDepth)
+		if err.HasError() {
+			return nil, score, err
+		}
+	}
+
+	if score > alpha && (scoutDepth < depthRemaining || score < beta) {
+		return fullWindow()
+	}
+
+	return future, score, NilError
+}
+
+// searchWithAspirationWindow searches the root with a narrow window around the
+// score from the previous iteration. If the best score falls outside of the
+// window, the window is widened and the root is searched again. The returned
+// ScoreType is only a bound if the search was stopped before a re-search.
+func (helper *SearchHelper) searchWithAspirationWindow(
+	depthRemaining int,
+	moves *[]Move,
+	knownVariations []Pair[int, []SearchMove],
+) ([]Pair[int, []SearchMove], ScoreType, SearchStatus, Error) {
+	alpha, beta := -InitialBounds(), InitialBounds()
+
+	delta := aspirationWindow
+	previousScore := 0
+	// Lines after the first need exact scores, which a window around the best
+	// score can't give
+	useAspirationWindow := !helper.WithoutAspirationWindows && helper.numLines() == 1
+	if useAspirationWindow && len(knownVariations) > 0 && !IsMate(knownVariations[0].First) {
+		previousScore = knownVariations[0].First
+		alpha, beta = previousScore-delta, previousScore+delta
+	}
+
+	for {
+		nextVariations, searchStatus, err := helper.SearchUpToDepth(depthRemaining, moves, alpha, beta)
+
+		SortMaxFirst(&nextVariations, func(t Pair[int, []SearchMove]) int {
+			return t.First
+		})
+
+		if err.HasError() || len(nextVariations) == 0 {
+			return nextVariations, Exact, searchStatus, err
+		}
+
+		score := nextVariations[0].First
+		bound := Exact
+		if score <= alpha && alpha > -InitialBounds() {
+			bound = AlphaFailUpperBound
+		} else if score >= beta && beta < InitialBounds() {
+			bound = BetaFailLowerBound
+		}
+
+		if bound == Exact || searchStatus != Completed {
+			return nextVariations, bound, searchStatus, err
+		}
+
+		delta *= aspirationWindowGrowth
+
+		if bound == AlphaFailUpperBound {
+			alpha = previousScore - delta
+			if delta > aspirationWindowMaximum {
+				alpha = -InitialBounds()
+			}
+		} else {
+			beta = previousScore + delta
+			if delta > aspirationWindowMaximum {
+				beta = InitialBounds()
+			}
+		}
+
+		helper.Debug.Println("aspiration re-search", depthRemaining, ScoreString(score), "window", alpha, beta)
+	}
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipalVariationSearchSearchesFewerMoves(t *testing.T) {
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	// The bounds that null window searches leave in the transposition table
+	// change which later nodes are cut off, so compare without it
+	with := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(6), WithoutTranspositionTable: true})
+	without := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(6), WithoutPrincipalVariationSearch: true, WithoutAspirationWindows: true, WithoutTranspositionTable: true})
+
+	fmt.Println("with pvs", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+}
+
+func TestIterativeDeepeningSearchesEveryDepth(t *testing.T) {
+	g, err := game.GamestateFromFenString("r3k2r/1bq1bppp/pp2p3/2p1n3/P3PP2/2PBN3/1P1BQ1PP/R4RK1 b kq - 0 16")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{MaxDepth: Some(4), CreateEvaluator: Some(CreateBasicEvaluator)})
+	defer unregister()
+
+	result, err := helper.Search()
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, 4, result.Depth)
+}
+
+func TestAspirationWindowMatchesFullWindow(t *testing.T) {
+	fen := "r3k2r/1bq1bppp/pp2p3/2p1n3/P3PP2/2PBN3/1P1BQ1PP/R4RK1 b kq - 0 16"
+
+	_, score, err := Search(fen, SearchOptions{MaxDepth: Some(4), CreateEvaluator: Some(CreateBasicEvaluator), WithoutTranspositionTable: true})
+	assert.True(t, IsNil(err), err)
+
+	_, expectedScore, err := Search(fen, SearchOptions{MaxDepth: Some(4), CreateEvaluator: Some(CreateBasicEvaluator), WithoutTranspositionTable: true, WithoutAspirationWindows: true, WithoutPrincipalVariationSearch: true})
+	assert.True(t, IsNil(err), err)
+
+	assert.Equal(t, expectedScore, score)
+}
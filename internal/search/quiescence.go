@@ -0,0 +1,161 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// defaultMaxQuiescencePlies caps how far quiescence searches past the end of
+// the main search, see SearchOptions.MaxQuiescencePlies
+const defaultMaxQuiescencePlies = 8
+
+// _deltaMargin is how much a capture might gain on top of the captured piece
+// from positional changes. Captures that can't raise the stand pat score to
+// alpha even with the margin are pruned.
+const _deltaMargin = 200
+
+// quiescence searches captures and promotions until the position is quiet, so
+// that positions aren't evaluated in the middle of an exchange. The first ply
+// also searches quiet checks. When in check, every evasion is searched.
+func (helper *SearchHelper) quiescence(alpha int, beta int, currentDepth int, quiescencePly int, past []SearchMove) ([]SearchMove, int, Error) {
+	if helper.treeRecorder == nil {
+		return helper.quiescenceNode(alpha, beta, currentDepth, quiescencePly, past)
+	}
+
+	helper.treeRecorder.enter(helper, alpha, beta, currentDepth, -quiescencePly, past)
+	future, score, err := helper.quiescenceNode(alpha, beta, currentDepth, quiescencePly, past)
+	helper.treeRecorder.exit(score)
+
+	return future, score, err
+}
+
+func (helper *SearchHelper) quiescenceNode(alpha int, beta int, currentDepth int, quiescencePly int, past []SearchMove) ([]SearchMove, int, Error) {
+	player := helper.GameState.Player
+
+	if helper.outOfTime() {
+		helper.recordCutoff("out-of-time")
+		return nil, Evaluate(helper.GameState.Bitboards, player), NilError
+	}
+
+	helper.nodes++
+	helper.selDepth = MaxInt(helper.selDepth, currentDepth)
+
+	standPat, err := helper.evaluate(player)
+	if err.HasError() {
+		return nil, alpha, err
+	}
+	if quiescencePly >= helper.MaxQuiescencePlies.ValueOr(defaultMaxQuiescencePlies) {
+		helper.recordCutoff("qs-depth")
+		return nil, MaxInt(alpha, MinInt(beta, standPat)), NilError
+	}
+
+	inCheck := helper.inCheck()
+
+	// When in check, the player has to respond to it rather than stand pat
+	useStandPat := !inCheck && !helper.WithoutCheckStandPat
+	if useStandPat {
+		if standPat >= beta {
+			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "sp-b-cut", Some(standPat))
+			helper.recordCutoff("sp-b-cut")
+			return nil, beta, NilError
+		} else if standPat > alpha {
+			alpha = standPat
+		}
+	}
+
+	mode := CapturesAndPromotions
+	if inCheck {
+		mode = AllMoves
+	} else if quiescencePly == 0 && !helper.WithoutQuiescenceChecks {
+		mode = CapturesPromotionsAndChecks
+	}
+
+	moves := &helper.ply(currentDepth).moves
+	result, err := helper.MoveGen.generateMoves(helper.GameState, mode, moves)
+	if err.HasError() {
+		return nil, alpha, err
+	}
+
+	sortMovesMaxFirst(moves, func(move Move) int {
+		return captureOrder(helper.GameState, move)
+	})
+
+	var principleVariation []SearchMove
+	foundMove := false
+	betaCutoff := false
+
+	for _, move := range *moves {
+		if useStandPat && helper.pruneQuiescenceMove(move, standPat, alpha) {
+			continue
+		}
+
+		searchMove := SearchMove{move, true}
+
+		legal, err := helper.performMove(currentDepth, move)
+		if err.HasError() {
+			return nil, alpha, err
+		}
+
+		if legal {
+			foundMove = true
+
+			future, enemyScore, err := helper.quiescence(-beta, -alpha, currentDepth+1, quiescencePly+1, append(past, searchMove))
+			if err.HasError() {
+				return nil, alpha, err
+			}
+			score := -enemyScore
+
+			if score >= beta {
+				alpha = beta
+				betaCutoff = true
+				helper.PrintlnVariation(helper.Debug, past, Some(searchMove), future, "b-cut", Some(score))
+			} else if score > alpha {
+				alpha = score
+				principleVariation = helper.principalVariation(currentDepth, searchMove, future)
+				helper.PrintlnVariation(helper.Debug, past, Some(searchMove), future, "pv", Some(score))
+			}
+		}
+
+		err = helper.undoMove(currentDepth)
+		if err.HasError() {
+			return nil, alpha, err
+		}
+
+		if betaCutoff {
+			break
+		}
+	}
+
+	if !foundMove {
+		if inCheck && result == AllLegalMoves {
+			helper.recordCutoff("mate")
+			return nil, MaxInt(alpha, MinInt(beta, MateBlackWins()+currentDepth)), NilError
+		}
+		if !useStandPat {
+			helper.recordCutoff("eval")
+			return nil, MaxInt(alpha, MinInt(beta, standPat)), NilError
+		}
+	}
+
+	return principleVariation, alpha, NilError
+}
+
+// pruneQuiescenceMove skips captures that can't raise the score to alpha.
+// Promotions and quiet checks are always searched.
+func (helper *SearchHelper) pruneQuiescenceMove(move Move, standPat int, alpha int) bool {
+	if !move.MoveType.Captures() || move.PromotionPiece.HasValue() {
+		return false
+	}
+
+	if !helper.WithoutDeltaPruning {
+		captured := _seeValues[Pawn]
+		if move.MoveType == CaptureMove {
+			captured = _seeValues[helper.GameState.Board[move.EndIndex].PieceType()]
+		}
+		if standPat+captured+_deltaMargin <= alpha {
+			return true
+		}
+	}
+
+	// Losing captures won't improve on standing pat
+	return !helper.WithoutSEEPruning && SEE(helper.GameState, move) < 0
+}
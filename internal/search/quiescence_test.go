@@ -0,0 +1,74 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func quiescence(t *testing.T, fen string, alpha int, beta int, options SearchOptions) (int, int) {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, options)
+	defer unregister()
+
+	_, score, err := helper.quiescence(alpha, beta, 0, 0, nil)
+	assert.True(t, IsNil(err), err)
+	return score, helper.Nodes()
+}
+
+func TestQuiescenceSearchesChecks(t *testing.T) {
+	fen := "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"
+
+	score, _ := quiescence(t, fen, -InitialBounds(), InitialBounds(), SearchOptions{})
+	assert.True(t, IsMate(score), ScoreString(score))
+
+	score, _ = quiescence(t, fen, -InitialBounds(), InitialBounds(), SearchOptions{WithoutQuiescenceChecks: true})
+	assert.False(t, IsMate(score), ScoreString(score))
+}
+
+func TestQuiescenceSearchesEvasions(t *testing.T) {
+	// Capturing the rook is the only evasion
+	fen := "4k3/8/8/8/8/8/r7/K1q5 w - - 0 1"
+	score, _ := quiescence(t, fen, -InitialBounds(), InitialBounds(), SearchOptions{})
+	assert.False(t, IsMate(score), ScoreString(score))
+
+	// The rook is defended
+	fen = "4k3/8/8/8/8/8/1r6/K1q5 w - - 0 1"
+	score, nodes := quiescence(t, fen, -InitialBounds(), InitialBounds(), SearchOptions{})
+	assert.True(t, IsMate(score), ScoreString(score))
+	assert.Equal(t, 1, nodes)
+}
+
+func TestQuiescenceSearchesPromotions(t *testing.T) {
+	fen := "8/4P3/8/8/8/k7/8/4K3 w - - 0 1"
+
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+	standPat := Evaluate(g.Bitboards, White)
+
+	score, _ := quiescence(t, fen, -InitialBounds(), InitialBounds(), SearchOptions{})
+	assert.Greater(t, score, standPat+500)
+}
+
+func TestQuiescenceDeltaPruning(t *testing.T) {
+	// White is a rook down, capturing a pawn won't get back to alpha
+	fen := "r3k3/p1p1p1p1/1P1P1P1P/8/8/8/8/4K3 w - - 0 1"
+
+	_, withDelta := quiescence(t, fen, 0, 1, SearchOptions{})
+	_, withoutDelta := quiescence(t, fen, 0, 1, SearchOptions{WithoutDeltaPruning: true})
+	assert.Less(t, withDelta, withoutDelta)
+}
+
+func TestQuiescenceDepthLimit(t *testing.T) {
+	fen := "r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 2 3"
+
+	_, nodes := quiescence(t, fen, -InitialBounds(), InitialBounds(), SearchOptions{MaxQuiescencePlies: Some(0)})
+	assert.Equal(t, 1, nodes)
+
+	_, nodes = quiescence(t, fen, -InitialBounds(), InitialBounds(), SearchOptions{MaxQuiescencePlies: Some(1)})
+	assert.Equal(t, 3, nodes)
+}
@@ -0,0 +1,31 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// AllowsRootMove checks a root move against IncludeRootMoves and
+// ExcludeRootMoves.
+func (o *SearchOptions) AllowsRootMove(move Move) bool {
+	name := move.String()
+	if len(o.IncludeRootMoves) > 0 && !Contains(o.IncludeRootMoves, name) {
+		return false
+	}
+	return !Contains(o.ExcludeRootMoves, name)
+}
+
+// filterRootMoves removes the root moves that shouldn't be searched. The
+// moves are filtered after generation so that this works with any MoveGen.
+func (helper *SearchHelper) filterRootMoves(moves *[]Move) {
+	if len(helper.IncludeRootMoves) == 0 && len(helper.ExcludeRootMoves) == 0 {
+		return
+	}
+
+	filtered := (*moves)[:0]
+	for _, move := range *moves {
+		if helper.AllowsRootMove(move) {
+			filtered = append(filtered, move)
+		}
+	}
+	*moves = filtered
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"testing"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludeRootMoves(t *testing.T) {
+	// a2a8 is mate
+	fen := "6k1/1R6/8/8/8/8/R7/6K1 w - - 0 1"
+
+	pv, mateInOne, err := Search(fen, SearchOptions{MaxDepth: Some(3)})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, "a2a8", pv[0].String())
+	assert.True(t, IsMate(mateInOne))
+
+	for _, options := range []SearchOptions{
+		{MaxDepth: Some(3), ExcludeRootMoves: []string{"a2a8"}},
+		{MaxDepth: Some(3), ExcludeRootMoves: []string{"a2a8"}, CreateMoveSorter: Some(CreateHistoryMoveSorter)},
+		{MaxDepth: Some(3), ExcludeRootMoves: []string{"a2a8"}, Threads: Some(2)},
+	} {
+		// There's still a slower mate, eg a2a3 and then a3a8
+		pv, score, err := Search(fen, options)
+		assert.True(t, IsNil(err), err)
+		assert.NotEqual(t, "a2a8", pv[0].String())
+		assert.Less(t, score, mateInOne, ScoreString(score))
+	}
+}
+
+func TestIncludeRootMoves(t *testing.T) {
+	fen := "6k1/1R6/8/8/8/8/R7/6K1 w - - 0 1"
+
+	pv, _, err := Search(fen, SearchOptions{MaxDepth: Some(3), IncludeRootMoves: []string{"g1h1", "g1f1"}})
+	assert.True(t, IsNil(err), err)
+	assert.Contains(t, []string{"g1h1", "g1f1"}, pv[0].String())
+
+	lines := searchMultiPV(t, fen, SearchOptions{MaxDepth: Some(3), MultiPV: Some(4), IncludeRootMoves: []string{"g1h1", "g1f1"}})
+	assert.Equal(t, 2, len(lines))
+
+	// The SearchTreeMoveGenerator restricts the whole tree, the include list
+	// only restricts the root
+	tree, err := SearchTreeFromLines([][]string{{"a2a8"}, {"b7b8"}}, true)
+	assert.True(t, IsNil(err), err)
+	pv, _, err = Search(fen, SearchOptions{
+		MaxDepth:         Some(3),
+		CreateMoveGen:    Some(CreateSearchTreeMoveGenerator(tree)),
+		IncludeRootMoves: []string{"b7b8"},
+	})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, "b7b8", pv[0].String())
+}
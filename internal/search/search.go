@@ -1,7 +1,11 @@
 package search
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
 
 	"github.com/cricklet/chessgo/internal/game"
 	. "github.com/cricklet/chessgo/internal/game"
@@ -91,8 +95,16 @@ type MoveGenerationMode int
 const (
 	AllMoves MoveGenerationMode = iota
 	OnlyCaptures
+	// Captures and quiet promotions, for quiescence
+	CapturesAndPromotions
+	// Also quiet moves that give direct check, for the first ply of quiescence
+	CapturesPromotionsAndChecks
+	// Every move that isn't in CapturesAndPromotions, including castling
+	QuietMoves
 )
 
+// MoveGenerationResult is whether a MoveGen left out any of the moves for the
+// requested mode, eg because it only searches some lines
 type MoveGenerationResult int
 
 const (
@@ -101,13 +113,31 @@ const (
 )
 
 type MoveGen interface {
-	generateMoves(game *game.GameState, mode MoveGenerationMode) (func(), MoveGenerationResult, *[]Move, Error)
+	// generateMoves replaces the contents of `moves`, the caller owns the
+	// buffer so that searching doesn't allocate
+	generateMoves(game *game.GameState, mode MoveGenerationMode, moves *[]Move) (MoveGenerationResult, Error)
+
+	// isPseudoLegal checks whether generating AllMoves would include `move`
+	isPseudoLegal(game *game.GameState, move Move) bool
 }
 
 type MoveSorter interface {
-	sortMoves(moves *[]Move) Error
+	// sortMoves orders the moves of the node `currentDepth` plies from the
+	// search root
+	sortMoves(currentDepth int, moves *[]Move) Error
+
+	// principalMoves are tried straight after the hash move, before anything
+	// is generated, see MovePicker
+	principalMoves(output *[]Move)
+	// killerMoves are quiet moves that are tried after the winning captures,
+	// before the rest of the quiet moves are generated
+	killerMoves(currentDepth int, output *[]Move)
+
 	reset(variations []Pair[int, []SearchMove])
 	copy() MoveSorter
+
+	// recordCutoff is called when `move` causes a beta cutoff in the current position
+	recordCutoff(move Move, currentDepth int, depthRemaining int)
 }
 
 type NoOpMoveSorter struct {
@@ -116,10 +146,16 @@ type NoOpMoveSorter struct {
 
 var _ MoveSorter = (*NoOpMoveSorter)(nil)
 
-func (s *NoOpMoveSorter) sortMoves(moves *[]Move) Error {
+func (s *NoOpMoveSorter) sortMoves(currentDepth int, moves *[]Move) Error {
 	return NilError
 }
 
+func (s *NoOpMoveSorter) principalMoves(output *[]Move) {
+}
+
+func (s *NoOpMoveSorter) killerMoves(currentDepth int, output *[]Move) {
+}
+
 func (s *NoOpMoveSorter) reset(variations []Pair[int, []SearchMove]) {
 }
 
@@ -127,13 +163,53 @@ func (s *NoOpMoveSorter) copy() MoveSorter {
 	return &NoOpMoveSorter{}
 }
 
+func (s *NoOpMoveSorter) recordCutoff(move Move, currentDepth int, depthRemaining int) {
+}
+
 type SearchHelper struct {
-	MoveGen      MoveGen
-	MoveSorter   MoveSorter
-	Evaluator    Evaluator
-	GameState    *GameState
-	OutOfTime    bool
-	InQuiescence bool
+	MoveGen            MoveGen
+	MoveSorter         MoveSorter
+	Evaluator          Evaluator
+	GameState          *GameState
+	TranspositionTable *TranspositionTable
+	TimeManager        *TimeManager
+	InQuiescence       bool
+
+	// Set while searching the reply to a null move, see nullMoveCutoff
+	inNullMove bool
+
+	// Plies the current line has been extended by, see extension
+	extensionPlies int
+	// Set while checking for singular moves, see isSingular
+	inSingularSearch bool
+
+	// Set when the search tree is recorded, see SearchTreeRecorder
+	treeRecorder *SearchTreeRecorder
+
+	// Set while searching, see SearchMultiPV and startWorkers. Once it is
+	// true the search unwinds as quickly as possible.
+	stopped *atomic.Bool
+	// Nodes visited by the current search, see MaxNodes
+	nodes int
+	// The deepest ply reached by the current search, including quiescence
+	selDepth int
+
+	// For the statistics in SearchResult
+	searchStart     time.Time
+	ttHitsAtStart   int64
+	ttProbesAtStart int64
+
+	// Buffers for each ply of the search, see plyState
+	plies     []*plyState
+	pastMoves []SearchMove
+
+	// Keeps the static evaluation up to date as moves are made, unless
+	// WithoutIncrementalEvaluation is set
+	incremental *IncrementalEvaluator
+
+	// Created by the first weakened search, see Skill
+	skillRand      *rand.Rand
+	skillNoiseSeed uint64
 	Logger
 	Debug Logger
 
@@ -146,6 +222,19 @@ func (helper *SearchHelper) String() string {
 	return helper.GameState.Board.String()
 }
 
+func (helper *SearchHelper) outOfTime() bool {
+	if maxNodes := helper.maxNodes(); maxNodes.HasValue() && helper.nodes >= maxNodes.Value() {
+		return true
+	}
+	return helper.stopped != nil && helper.stopped.Load()
+}
+
+// Nodes is the number of nodes visited by the last search. Workers started
+// for Threads aren't counted.
+func (helper *SearchHelper) Nodes() int {
+	return helper.nodes
+}
+
 func (helper *SearchHelper) inCheck() bool {
 	return KingIsInCheck(helper.GameState.Bitboards, helper.GameState.Player)
 }
@@ -159,6 +248,9 @@ type SearchMove struct {
 }
 
 func (move SearchMove) String() string {
+	if move == nullSearchMove {
+		return "0000"
+	}
 	return move.DebugString()
 }
 
@@ -207,10 +299,19 @@ func (helper *SearchHelper) PrintlnVariation(logger Logger,
 	label string,
 	score Optional[int],
 ) {
-	if logger == &SilentLogger {
-		return
+	// Small enough to be inlined, so that nothing is called when debugging is off
+	if logger != &SilentLogger {
+		helper.printlnVariation(logger, past, current, future, label, score)
 	}
+}
 
+func (helper *SearchHelper) printlnVariation(logger Logger,
+	past []SearchMove,
+	current Optional[SearchMove],
+	future []SearchMove,
+	label string,
+	score Optional[int],
+) {
 	fullVariation := []SearchMove{}
 	fullVariation = append(fullVariation, past...)
 	if current.HasValue() {
@@ -230,150 +331,285 @@ func (helper *SearchHelper) PrintlnVariation(logger Logger,
 	logger.Println(result)
 }
 
-func (helper *SearchHelper) alphaBeta(alpha int, beta int, currentDepth int, depthRemaining int, past []SearchMove) ([]SearchMove, int, Error) {
-	if helper.OutOfTime {
+func (helper *SearchHelper) alphaBetaNode(alpha int, beta int, currentDepth int, depthRemaining int, past []SearchMove) ([]SearchMove, int, Error) {
+	if helper.outOfTime() {
+		helper.recordCutoff("out-of-time")
 		return nil, Evaluate(helper.GameState.Bitboards, helper.GameState.Player), NilError
 	}
 
+	helper.nodes++
+	helper.selDepth = MaxInt(helper.selDepth, currentDepth)
+
+	if currentDepth > 0 {
+		draw, err := helper.isDraw(currentDepth)
+		if err.HasError() {
+			return nil, alpha, err
+		}
+		if draw {
+			score := helper.drawScore(currentDepth)
+			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "draw", Some(score))
+			helper.recordCutoff("draw")
+			return nil, MaxInt(alpha, MinInt(beta, score)), NilError
+		}
+	}
+
+	if currentDepth > 0 && !helper.WithoutMateDistancePruning {
+		alpha, beta = mateDistanceBounds(alpha, beta, currentDepth)
+		if alpha >= beta {
+			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "mate-dist", Some(alpha))
+			helper.recordCutoff("mate-dist")
+			return nil, alpha, NilError
+		}
+	}
+
+	if currentDepth > 0 {
+		if score := helper.probeTablebase(currentDepth); score.HasValue() {
+			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "tb", score)
+			helper.recordCutoff("tb")
+			return nil, MaxInt(alpha, MinInt(beta, score.Value())), NilError
+		}
+	}
+
 	if depthRemaining <= 0 {
+		helper.recordCutoff("eval")
 		future, score, err := helper.Evaluator.evaluate(helper, helper.GameState.Player, alpha, beta, currentDepth, past)
 		// helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), future, "eval", Some(score))
 		return future, score, err
 	}
 
-	if !helper.WithoutCheckStandPat {
-		// if we decide not to not take (eg make a neutral move / stand-pat)
-		// and that's really good for us (eg other player will have prevented this path)
-		//   we can return early
-		// if it's good for us but not so good the other player can prevent this path
-		//   we need to search captures
-		//   but we can also update alpha
-		//   because the future capture must beat standing pat in order for us to choose it
-		// if it's bad for us, we need to search captures
-		_, standPat, err := BasicEvaluator{}.evaluate(helper, helper.GameState.Player, alpha, beta, currentDepth, past)
+	hash := helper.GameState.ZobristHash()
+	hashMove := Empty[Move]()
+
+	useTranspositionTable := helper.TranspositionTable != nil
+	if useTranspositionTable {
+		var cached Optional[CachedEvaluation]
+		cached, hashMove = helper.TranspositionTable.Get(hash, depthRemaining)
+		if cached.HasValue() {
+			entry := cached.Value()
+			score := scoreFromTranspositionTable(entry.Score, currentDepth)
+
+			var future []SearchMove
+			if entry.BestMove.HasValue() {
+				future = helper.principalVariation(currentDepth, SearchMove{entry.BestMove.Value(), false}, nil)
+			}
+
+			switch entry.ScoreType {
+			case Exact:
+				helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), future, "tt", Some(score))
+				helper.recordCutoff("tt")
+				return future, MaxInt(alpha, MinInt(beta, score)), NilError
+			case BetaFailLowerBound:
+				if score >= beta {
+					helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), future, "tt-b-cut", Some(score))
+					helper.recordCutoff("tt-b-cut")
+					return nil, beta, NilError
+				}
+			case AlphaFailUpperBound:
+				if score <= alpha {
+					helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), future, "tt-a-cut", Some(score))
+					helper.recordCutoff("tt-a-cut")
+					return nil, alpha, NilError
+				}
+			}
+		}
+	}
+
+	originalAlpha := alpha
+	var bestMove Optional[Move]
+
+	inCheck := helper.inCheck()
+
+	// The static eval is only needed for pruning near the leaves, which isn't
+	// done when in check
+	staticEval := Empty[int]()
+	if !inCheck && depthRemaining <= helper.maxFrontierDepth() {
+		score, err := helper.evaluate(helper.GameState.Player)
 		if err.HasError() {
 			return nil, alpha, err
 		}
+		staticEval = Some(score)
+	}
 
-		if !helper.InQuiescence {
-			standPat = standPat - 50
+	if staticEval.HasValue() && helper.reverseFutilityCutoff(staticEval.Value(), beta, depthRemaining) {
+		helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "rfp-b-cut", staticEval)
+		helper.recordCutoff("rfp-b-cut")
+		return nil, beta, NilError
+	}
+
+	if staticEval.HasValue() && helper.canRazor(staticEval.Value(), alpha, depthRemaining) {
+		future, score, err := helper.Evaluator.evaluate(helper, helper.GameState.Player, alpha, beta, currentDepth, past)
+		if err.HasError() {
+			return nil, alpha, err
 		}
+		if score <= alpha {
+			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), future, "razor", Some(score))
+			helper.recordCutoff("razor")
+			return nil, alpha, NilError
+		}
+	}
 
-		if standPat >= beta {
-			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "sp-b-cut", Some(standPat))
+	if helper.canTryNullMove(beta, depthRemaining) {
+		cutoff, err := helper.nullMoveCutoff(beta, currentDepth, depthRemaining, past)
+		if err.HasError() {
+			return nil, alpha, err
+		}
+		if cutoff {
+			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "null-b-cut", Some(beta))
+			helper.recordCutoff("null-b-cut")
 			return nil, beta, NilError
-		} else if standPat > alpha {
-			helper.PrintlnVariation(helper.Debug, past, Empty[SearchMove](), nil, "sp-alpha", Some(standPat))
-			alpha = standPat
 		}
 	}
 
 	var principleVariation []SearchMove = nil
 
-	mode := AllMoves
-	if helper.InQuiescence {
-		mode = OnlyCaptures
-	}
-
 	foundMove := false
 
-	cleanup, result, moves, err := helper.MoveGen.generateMoves(helper.GameState, mode)
-	defer cleanup()
+	picker := helper.movePicker(currentDepth, hashMove)
 
-	if err.HasError() {
-		return nil, alpha, err
-	}
+	futile := staticEval.HasValue() && helper.futile(staticEval.Value(), alpha, depthRemaining)
+	numLegalMoves := 0
 
-	err = helper.MoveSorter.sortMoves(moves)
-	if err.HasError() {
-		return nil, alpha, err
+	singularMove := Empty[Move]()
+	if helper.TranspositionTable != nil && helper.canTrySingularExtension(hashMove, depthRemaining) {
+		singular, err := helper.isSingular(hash, hashMove.Value(), currentDepth, depthRemaining, past)
+		if err.HasError() {
+			return nil, alpha, err
+		}
+		if singular {
+			singularMove = hashMove
+		}
 	}
 
-	for _, move := range *moves {
-		betaCutoff := false
-		searchMove := SearchMove{move, helper.InQuiescence}
+	betaCutoff := false
+	for {
+		next, err := picker.next()
+		if err.HasError() {
+			return nil, alpha, err
+		}
+		if next.IsEmpty() {
+			break
+		}
+		move := next.Value()
+		searchMove := SearchMove{move, false}
 
 		helper.PrintlnVariation(helper.Debug, past, Some(searchMove), nil, "???", Empty[int]())
 
-		undo, legal, err := performMoveAndReturnLegality(helper.GameState, move)
+		legal, err := helper.performMove(currentDepth, move)
 		if err.HasError() {
 			return nil, alpha, err
 		}
 
 		if legal {
 			foundMove = true
-			future, enemyScore, err := helper.alphaBeta(-beta, -alpha, currentDepth+1, depthRemaining-1, append(past, searchMove))
 
-			if err.HasError() {
-				return nil, alpha, err
-			}
+			givesCheck := helper.inCheck()
 
-			if IsMate(enemyScore) {
-				enemyScore, err = IncrementMate(enemyScore)
+			// Once a move has been searched, quiet moves can't raise a futile
+			// node to alpha
+			if futile && numLegalMoves > 0 && isQuietMove(move) && !givesCheck {
+				helper.recordCutoff("futile")
+				err = helper.undoMove(currentDepth)
 				if err.HasError() {
 					return nil, alpha, err
 				}
+				continue
+			}
+
+			extension := helper.extension(move, past, givesCheck, singularMove == Some(move))
+			reduction := 0
+			if extension == 0 {
+				reduction = helper.lateMoveReduction(move, numLegalMoves, depthRemaining, inCheck, givesCheck)
+			}
+
+			helper.extensionPlies += extension
+			future, score, err := helper.searchMove(alpha, beta, currentDepth+1, depthRemaining-1+extension, reduction, numLegalMoves == 0, append(past, searchMove))
+			helper.extensionPlies -= extension
+
+			if err.HasError() {
+				return nil, alpha, err
 			}
 
-			score := -enemyScore
+			numLegalMoves++
+
 			if score >= beta {
 				alpha = beta // fail hard beta-cutoff
 				betaCutoff = true
+				bestMove = Some(move)
 				helper.PrintlnVariation(helper.Debug, past, Some(searchMove), future, "b-cut", Some(score))
 			} else if score > alpha {
 				alpha = score
+				bestMove = Some(move)
 				helper.PrintlnVariation(helper.Debug, past, Some(searchMove), future, "pv", Some(score))
-				principleVariation = append([]SearchMove{searchMove}, future...)
+				principleVariation = helper.principalVariation(currentDepth, searchMove, future)
 			} else {
 				helper.PrintlnVariation(helper.Debug, past, Some(searchMove), future, "a-skip", Some(score))
 			}
 		}
 
-		err = undo()
+		err = helper.undoMove(currentDepth)
 		if err.HasError() {
 			return nil, alpha, err
 		}
 
 		if betaCutoff {
+			helper.MoveSorter.recordCutoff(move, currentDepth, depthRemaining)
 			break
 		}
 	}
 
-	if err.HasError() {
-		return nil, alpha, err
-	}
-
 	if !foundMove {
-		if result == AllLegalMoves {
-			if helper.InQuiescence {
-				return nil, alpha, Errorf("quiescence should only search captures")
-			}
+		if picker.result == AllLegalMoves {
 			// If no legal moves exist, we're in stalemate or checkmate
 			if helper.inCheck() {
-				alpha = MateBlackWins()
+				// Mate scores are relative to the root, so faster mates score higher
+				alpha = MateBlackWins() + currentDepth
+				helper.recordCutoff("mate")
 			} else {
-				alpha = 0
+				alpha = helper.drawScore(currentDepth)
+				helper.recordCutoff("stalemate")
 			}
 		} else {
+			helper.recordCutoff("eval")
 			return helper.Evaluator.evaluate(helper, helper.GameState.Player, alpha, beta, currentDepth, past)
 		}
 	}
 
+	if useTranspositionTable && !helper.outOfTime() {
+		scoreType := AlphaFailUpperBound
+		if betaCutoff {
+			scoreType = BetaFailLowerBound
+		} else if alpha > originalAlpha || !foundMove {
+			scoreType = Exact
+		}
+
+		helper.TranspositionTable.Put(hash, depthRemaining,
+			scoreToTranspositionTable(alpha, currentDepth), scoreType, bestMove)
+	}
+
 	return principleVariation, alpha, NilError
 }
 
-type SearchResult int
+// SearchStatus is how a search ended
+type SearchStatus int
 
 const (
-	OutOfTime SearchResult = iota
+	OutOfTime SearchStatus = iota
 	Failed
 	Completed
+	// The search was stopped early by cancelling its context
+	Interrupted
 )
 
+// SearchUpToDepth searches each root move within the (alpha, beta) window. The
+// first MultiPV legal moves are searched with the full window and later moves
+// with a null window, see searchMove. Scores that fall outside the window are
+// only bounds.
 func (helper *SearchHelper) SearchUpToDepth(
 	depthRemaining int,
 	moves *[]Move,
-) ([]Pair[int, []SearchMove], SearchResult, Error) {
+	alpha int,
+	beta int,
+) ([]Pair[int, []SearchMove], SearchStatus, Error) {
 	var err Error
 
 	// The next set of principle variations will go here
@@ -383,96 +619,183 @@ func (helper *SearchHelper) SearchUpToDepth(
 		return nextVariations, Failed, err
 	}
 
-	err = helper.MoveSorter.sortMoves(moves)
+	numLines := helper.numLines()
+	originalAlpha := alpha
+
+	if helper.treeRecorder != nil {
+		helper.treeRecorder.startRoot(depthRemaining, alpha, beta)
+		defer func() { helper.treeRecorder.finishRoot(nextVariations) }()
+	}
+
+	err = helper.MoveSorter.sortMoves(0, moves)
 	if err.HasError() {
 		return nextVariations, Failed, err
 	}
 
 	for _, move := range *moves {
-		if helper.OutOfTime {
+		if helper.outOfTime() {
 			return nextVariations, OutOfTime, NilError
 		}
 
-		undo, legal, err := performMoveAndReturnLegality(helper.GameState, move)
+		legal, err := helper.performMove(0, move)
 		if err.HasError() {
 			return nextVariations, Failed, err
 		}
 
+		betaCutoff := false
+
 		if legal {
+			extension := helper.extension(move, nil, helper.inCheck(), false)
+
 			// Traverse past the first generated move
-			variation, enemyScore, err := helper.alphaBeta(-InitialBounds(), InitialBounds(),
+			helper.extensionPlies += extension
+			variation, score, err := helper.searchMove(alpha, beta,
 				// current depth is 1 (0 would be before we applied `move`)
 				1,
 				// we've already searched one move, so decrement depth remaining
-				depthRemaining-1,
-				[]SearchMove{{move, false}})
+				depthRemaining-1+extension,
+				0,
+				len(nextVariations) < numLines,
+				append(helper.rootPast(), SearchMove{move, false}))
+			helper.extensionPlies -= extension
 
 			if err.HasError() {
 				return nextVariations, Failed, err
 			}
 
-			if IsMate(enemyScore) {
-				enemyScore, err = IncrementMate(enemyScore)
-				if err.HasError() {
-					return nextVariations, Failed, err
-				}
-			}
-
-			score := -enemyScore
 			nextVariations = append(nextVariations, Pair[int, []SearchMove]{
 				First: score, Second: append([]SearchMove{{move, false}}, variation...)})
+
+			if score >= beta {
+				// The aspiration window was too narrow, the caller will re-search
+				betaCutoff = true
+			} else {
+				alpha = helper.rootAlpha(originalAlpha, nextVariations)
+			}
 		}
 
-		err = undo()
+		err = helper.undoMove(0)
 		if err.HasError() {
 			return nextVariations, Failed, err
 		}
+
+		if betaCutoff {
+			break
+		}
 	}
 
 	return nextVariations, Completed, NilError
 }
 
-func (helper *SearchHelper) Search() ([]Move, int, int, Error) {
-	knownVariations := []Pair[int, []SearchMove]{}
+func (helper *SearchHelper) Search() (SearchResult, Error) {
+	return helper.SearchMultiPV(context.Background())
+}
+
+// SearchMultiPV returns the best MultiPV lines from the root, best first. Each
+// line has an exact score. With a Skill, the line it chose to play is first.
+//
+// Cancelling `ctx` stops the search from any goroutine. The lines from the
+// last completed iteration are still returned, along with Interrupted.
+// OutOfTime is returned if the TimeManager or MaxNodes cut the search short.
+func (helper *SearchHelper) SearchMultiPV(ctx context.Context) (SearchResult, Error) {
+	helper.startStatistics()
+
+	// Each search gets its own flags so that a late timer or cancellation
+	// can't stop the next search
+	stopped := &atomic.Bool{}
+	interrupted := &atomic.Bool{}
+
+	helper.stopped = stopped
+	defer func() { helper.stopped = nil }()
+
+	searchDone := make(chan struct{})
+	defer close(searchDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			interrupted.Store(true)
+			stopped.Store(true)
+		case <-searchDone:
+		}
+	}()
+
+	if helper.TimeManager != nil {
+		stopTimer := helper.TimeManager.startTimer(func() {
+			stopped.Store(true)
+		})
+		defer stopTimer()
+	}
+
+	stopWorkers := helper.startWorkers()
+
+	best, status, err := helper.iterativeDeepening(0)
+
+	err = Join(err, stopWorkers())
 
-	depthIncrement := 2
+	best.variations = helper.pickSkillLine(best.variations)
+
+	if status == OutOfTime && interrupted.Load() {
+		status = Interrupted
+	}
 
-	startDepthRemaining := 1
+	return helper.searchResult(best, status), err
+}
+
+// iteration is the result of searching the root to `depth`
+type iteration struct {
+	variations []Pair[int, []SearchMove]
+	depth      int
+	bound      ScoreType
+}
+
+// iterativeDeepening returns the variations from the deepest completed
+// iteration. The status is OutOfTime if the search was stopped before reaching
+// the max depth.
+func (helper *SearchHelper) iterativeDeepening(startDepthOffset int) (iteration, SearchStatus, Error) {
+	known := iteration{bound: Exact}
+
+	depthIncrement := 1
+
+	startDepthRemaining := 1 + startDepthOffset
 	if helper.WithoutIterativeDeepening {
-		startDepthRemaining = helper.MaxDepth.ValueOr(defaultMaxDepth)
+		startDepthRemaining = helper.maxDepth()
 	}
 
-	cleanup, _, moves, err := helper.MoveGen.generateMoves(helper.GameState, AllMoves)
-	defer cleanup()
+	moves := &[]Move{}
+	_, err := helper.MoveGen.generateMoves(helper.GameState, AllMoves, moves)
+	if err.HasError() {
+		return known, Failed, err
+	}
 
-	searchedDepth := 0
+	helper.filterRootMoves(moves)
 
+	err = helper.filterTablebaseRootMoves(moves)
 	if err.HasError() {
-		return nil, 0, searchedDepth, err
+		return known, Failed, err
 	}
 
 	doneEarly := false
+	status := Completed
 
-	for depthRemaining := startDepthRemaining; !doneEarly && depthRemaining <= helper.MaxDepth.ValueOr(defaultMaxDepth); depthRemaining += depthIncrement {
+	for depthRemaining := startDepthRemaining; !doneEarly && depthRemaining <= helper.maxDepth(); depthRemaining += depthIncrement {
 		// The generator will prioritize trying the principle variations first
-		helper.MoveSorter.reset(knownVariations)
+		helper.MoveSorter.reset(known.variations)
 
-		nextVariations, searchResult, err := helper.SearchUpToDepth(depthRemaining, moves)
+		nextVariations, bound, searchStatus, err := helper.searchWithAspirationWindow(depthRemaining, moves, known.variations)
 
 		if err.HasError() {
-			return nil, 0, searchedDepth, err
+			return iteration{}, Failed, err
 		}
 
-		if searchResult == OutOfTime && len(knownVariations) > 0 {
-			break
+		if searchStatus == OutOfTime {
+			status = OutOfTime
+			if len(known.variations) > 0 {
+				break
+			}
 		}
 
-		SortMaxFirst(&nextVariations, func(t Pair[int, []SearchMove]) int {
-			return t.First
-		})
-
 		for i, move := range nextVariations {
-			if i > 5 {
+			if i > 5 || helper.Logger == &SilentLogger {
 				break
 			}
 
@@ -490,23 +813,19 @@ func (helper *SearchHelper) Search() ([]Move, int, int, Error) {
 		}
 		helper.Debug.Println()
 
-		knownVariations = nextVariations
+		known = iteration{variations: nextVariations, depth: depthRemaining, bound: bound}
 
-		if err.HasError() {
-			return nil, 0, searchedDepth, err
+		if searchStatus == Completed && helper.OnIteration.HasValue() {
+			helper.OnIteration.Value()(helper.searchResult(known, Completed))
 		}
 
-		searchedDepth = depthRemaining
-	}
-
-	if len(knownVariations) == 0 {
-		return nil, 0, searchedDepth, NilError
+		if helper.TimeManager != nil && searchStatus == Completed && len(known.variations) > 0 {
+			best := known.variations[0]
+			doneEarly = helper.TimeManager.shouldStop(best.Second[0].Move, best.First)
+		}
 	}
 
-	bestMove := knownVariations[0]
-	return MapSlice(bestMove.Second, func(m SearchMove) Move {
-		return m.Move
-	}), bestMove.First, searchedDepth, NilError
+	return known, status, NilError
 }
 
 type MoveGenConstructor func(*GameState) (func(), MoveGen)
@@ -521,9 +840,67 @@ type SearchOptions struct {
 	CreateMoveSorter Optional[MoveSorterConstructor]
 	CreateEvaluator  Optional[EvaluatorConstructor]
 
-	WithoutIterativeDeepening bool
-	WithoutCheckStandPat      bool
-	MaxDepth                  Optional[int]
+	WithoutIterativeDeepening       bool
+	WithoutCheckStandPat            bool
+	WithoutTranspositionTable       bool
+	WithoutNullMovePruning          bool
+	WithoutLateMoveReductions       bool
+	WithoutPrincipalVariationSearch bool
+	WithoutAspirationWindows        bool
+	WithoutSEEPruning               bool
+	WithoutMateDistancePruning      bool
+	WithoutCheckExtensions          bool
+	WithoutPassedPawnExtensions     bool
+	WithoutSingularExtensions       bool
+	WithoutFutilityPruning          bool
+	WithoutReverseFutilityPruning   bool
+	WithoutRazoring                 bool
+	MaxExtensionPlies               Optional[int]
+	MaxQuiescencePlies              Optional[int]
+	WithoutDeltaPruning             bool
+	WithoutQuiescenceChecks         bool
+	WithoutIncrementalEvaluation    bool
+	WithoutStagedMoveGeneration     bool
+	Contempt                        int
+	MaxDepth                        Optional[int]
+	MultiPV                         Optional[int]
+	Threads                         Optional[int]
+
+	// Recaptures are only extended when this is set. The extra ply searches
+	// past the end of lines that are otherwise complete.
+	RecaptureExtensions bool
+
+	// Stops the search after visiting this many nodes. Searches with the same
+	// node budget are deterministic, so Threads is ignored when it's set.
+	MaxNodes Optional[int]
+
+	// Margins for the pruning near the leaves, indexed by the depth remaining
+	// minus one, see search_pruning.go. Pruning is only done within
+	// len(margins) plies of the leaves.
+	FutilityMargins        []int
+	ReverseFutilityMargins []int
+	RazoringMargins        []int
+
+	TranspositionTableSizeInBytes Optional[int]
+	// Shared by searches that should reuse each other's results, eg the
+	// searches of one game. A new table is created when it's empty.
+	TranspositionTable Optional[*TranspositionTable]
+
+	TreeRecorder Optional[*SearchTreeRecorder]
+
+	// Probed at the root and in alphaBeta, see the tablebase package
+	Tablebase Optional[Tablebase]
+
+	// Root moves in UCI notation, eg "e2e4". If IncludeRootMoves isn't empty,
+	// only those moves are searched.
+	IncludeRootMoves []string
+	ExcludeRootMoves []string
+
+	// Weakens the search, see Skill
+	Skill Optional[Skill]
+
+	// Called on the searching goroutine after each depth is completed
+	OnIteration Optional[func(SearchResult)]
 
 	// Add option
 }
@@ -536,19 +913,39 @@ var defaultMaxDepth = 10
 
 type SearchHelperConstructor func(*GameState) (func(), *SearchHelper)
 
+// SearchHelperFromOptions returns a constructor for helpers that all share one
+// transposition table
 func SearchHelperFromOptions(options SearchOptions) SearchHelperConstructor {
+	if !options.WithoutTranspositionTable && options.TranspositionTable.IsEmpty() {
+		options.TranspositionTable = Some(NewTranspositionTable(
+			options.TranspositionTableSizeInBytes.ValueOr(DefaultTranspositionTableSizeInBytes)))
+	}
+
 	return func(game *game.GameState) (func(), *SearchHelper) {
 		return NewSearchHelper(game, options)
 	}
 }
 
 func NewSearchHelper(game *GameState, options SearchOptions) (func(), *SearchHelper) {
+	var table *TranspositionTable
+	if options.TranspositionTable.HasValue() && !options.WithoutTranspositionTable {
+		table = options.TranspositionTable.Value()
+	} else if !options.WithoutTranspositionTable {
+		table = NewTranspositionTable(
+			options.TranspositionTableSizeInBytes.ValueOr(DefaultTranspositionTableSizeInBytes))
+	}
+
+	return newSearchHelper(game, options, table)
+}
+
+func newSearchHelper(game *GameState, options SearchOptions, table *TranspositionTable) (func(), *SearchHelper) {
 	unregisterCallbacks := []func(){}
 
 	helper := SearchHelper{
-		GameState: game,
-		Logger:    &SilentLogger,
-		Debug:     &SilentLogger,
+		GameState:          game,
+		TranspositionTable: table,
+		Logger:             &SilentLogger,
+		Debug:              &SilentLogger,
 
 		SearchOptions: options,
 	}
@@ -564,6 +961,17 @@ func NewSearchHelper(game *GameState, options SearchOptions) (func(), *SearchHel
 		helper.Debug = options.DebugLogger.Value()
 	}
 
+	if options.TreeRecorder.HasValue() {
+		helper.treeRecorder = options.TreeRecorder.Value()
+	}
+
+	if !options.WithoutIncrementalEvaluation {
+		// When debugging, each evaluation is checked against Evaluate
+		unregister, incremental := NewIncrementalEvaluator(game, options.DebugLogger.HasValue())
+		unregisterCallbacks = append(unregisterCallbacks, unregister)
+		helper.incremental = incremental
+	}
+
 	if options.CreateEvaluator.HasValue() {
 		unregister, evaluator := options.CreateEvaluator.Value()(game)
 		unregisterCallbacks = append(unregisterCallbacks, unregister)
@@ -577,8 +985,7 @@ func NewSearchHelper(game *GameState, options SearchOptions) (func(), *SearchHel
 		unregisterCallbacks = append(unregisterCallbacks, unregister)
 		helper.MoveGen = moveGen
 	} else {
-		defaultMoveGenerator := DefaultMoveGenerator{}
-		helper.MoveGen = &defaultMoveGenerator
+		helper.MoveGen = NewDefaultMoveGenerator()
 	}
 
 	if options.CreateMoveSorter.HasValue() {
@@ -607,6 +1014,6 @@ func Search(fen string, options SearchOptions) ([]Move, int, Error) {
 	unregister, helper := NewSearchHelper(game, options)
 	defer unregister()
 
-	pv, score, _, err := helper.Search()
-	return pv, score, err
+	result, err := helper.Search()
+	return result.PV, result.Score, err
 }
@@ -9,9 +9,9 @@ import (
 
 func TestBoardPrint(t *testing.T) {
 	assert.Equal(t, UnwrapReturn(MateInNScore(1)), Inf-1)
-	assert.Equal(t, UnwrapReturn(MateInNScore(2)), Inf-2)
-	assert.Equal(t, UnwrapReturn(MateInNScore(-1)), -Inf+1)
-	assert.Equal(t, UnwrapReturn(MateInNScore(-2)), -Inf+2)
+	assert.Equal(t, UnwrapReturn(MateInNScore(2)), Inf-3)
+	assert.Equal(t, UnwrapReturn(MateInNScore(-1)), -Inf+2)
+	assert.Equal(t, UnwrapReturn(MateInNScore(-2)), -Inf+4)
 
 	assert.True(t, IsMate(UnwrapReturn(MateInNScore(1))))
 	assert.True(t, IsMate(UnwrapReturn(MateInNScore(2))))
@@ -22,4 +22,18 @@ func TestBoardPrint(t *testing.T) {
 	assert.Equal(t, ScoreString(UnwrapReturn(MateInNScore(2))), "mate+2")
 	assert.Equal(t, ScoreString(UnwrapReturn(MateInNScore(-1))), "mate-1")
 	assert.Equal(t, ScoreString(UnwrapReturn(MateInNScore(-2))), "mate-2")
+
+	for _, n := range []int{1, 2, 7, -1, -2, -7} {
+		assert.Equal(t, n, MateInN(UnwrapReturn(MateInNScore(n))))
+	}
+	assert.Equal(t, 0, MateInN(150))
+
+	assert.Equal(t, "mate 2", UciScoreString(UnwrapReturn(MateInNScore(2))))
+	assert.Equal(t, "mate -1", UciScoreString(UnwrapReturn(MateInNScore(-1))))
+	assert.Equal(t, "cp -35", UciScoreString(-35))
+
+	// If the enemy is mated in 1 after our move, we mate in 2
+	assert.Equal(t, UnwrapReturn(MateInNScore(2)), ScoreFromEnemyScore(UnwrapReturn(MateInNScore(-1))))
+	assert.Equal(t, UnwrapReturn(MateInNScore(-1)), ScoreFromEnemyScore(UnwrapReturn(MateInNScore(1))))
+	assert.Equal(t, 35, ScoreFromEnemyScore(-35))
 }
@@ -0,0 +1,165 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+const (
+	nullMoveReduction         = 2
+	nullMoveMinDepth          = 3
+	lateMoveReductionPlies    = 1
+	lateMoveReductionMinDepth = 3
+	lateMoveReductionMinIndex = 3
+)
+
+// hasNonPawnMaterial is used to avoid null-move pruning in king & pawn
+// endgames, where passing would often be the best move (zugzwang).
+func hasNonPawnMaterial(g *GameState, player Player) bool {
+	pieces := g.Bitboards.Players[player].Pieces
+	return pieces[Knight]|pieces[Bishop]|pieces[Rook]|pieces[Queen] != 0
+}
+
+func (helper *SearchHelper) canTryNullMove(beta int, depthRemaining int) bool {
+	if helper.WithoutNullMovePruning || helper.InQuiescence || helper.inNullMove {
+		return false
+	}
+	if depthRemaining < nullMoveMinDepth || IsMate(beta) {
+		return false
+	}
+	return !helper.inCheck() && hasNonPawnMaterial(helper.GameState, helper.GameState.Player)
+}
+
+// nullSearchMove stands for a null move in `past`. It isn't a capture, so the
+// move after it is never a recapture.
+var nullSearchMove = SearchMove{}
+
+// nullMoveCutoff lets the other player move twice in a row. If a reduced
+// search still fails high, the position is good enough that a real move will
+// almost certainly fail high as well.
+func (helper *SearchHelper) nullMoveCutoff(beta int, currentDepth int, depthRemaining int, past []SearchMove) (bool, Error) {
+	update := &helper.ply(currentDepth).update
+	err := helper.GameState.PerformNullMove(update)
+	if err.HasError() {
+		return false, err
+	}
+
+	helper.inNullMove = true
+	_, enemyScore, err := helper.alphaBeta(-beta, -beta+1, currentDepth+1, depthRemaining-1-nullMoveReduction, append(past, nullSearchMove))
+	helper.inNullMove = false
+
+	undoErr := helper.GameState.UndoNullMove(update)
+	if err.HasError() || undoErr.HasError() {
+		return false, Join(err, undoErr)
+	}
+
+	return -enemyScore >= beta, NilError
+}
+
+// lateMoveReduction returns how much shallower to search a move that was
+// sorted late in the move list. The move must already have been applied.
+func (helper *SearchHelper) lateMoveReduction(move Move, legalIndex int, depthRemaining int, inCheck bool, givesCheck bool) int {
+	if helper.WithoutLateMoveReductions || helper.InQuiescence || inCheck {
+		return 0
+	}
+	if legalIndex < lateMoveReductionMinIndex || depthRemaining < lateMoveReductionMinDepth {
+		return 0
+	}
+	if move.MoveType.Captures() || move.PromotionPiece.HasValue() {
+		return 0
+	}
+	if givesCheck {
+		return 0
+	}
+	return lateMoveReductionPlies
+}
+
+// The default margins for pruning near the leaves, indexed by the depth
+// remaining minus one. See SearchOptions.FutilityMargins.
+var (
+	defaultFutilityMargins        = []int{150, 300}
+	defaultReverseFutilityMargins = []int{100, 200, 300}
+	defaultRazoringMargins        = []int{300, 500}
+)
+
+// pruningMargin returns the margin at `depthRemaining`, or nothing if that's
+// too far from the leaves to prune
+func pruningMargin(margins []int, depthRemaining int) Optional[int] {
+	if depthRemaining < 1 || depthRemaining > len(margins) {
+		return Empty[int]()
+	}
+	return Some(margins[depthRemaining-1])
+}
+
+func (helper *SearchHelper) futilityMargins() []int {
+	if helper.WithoutFutilityPruning {
+		return nil
+	} else if helper.FutilityMargins != nil {
+		return helper.FutilityMargins
+	}
+	return defaultFutilityMargins
+}
+
+func (helper *SearchHelper) reverseFutilityMargins() []int {
+	if helper.WithoutReverseFutilityPruning {
+		return nil
+	} else if helper.ReverseFutilityMargins != nil {
+		return helper.ReverseFutilityMargins
+	}
+	return defaultReverseFutilityMargins
+}
+
+func (helper *SearchHelper) razoringMargins() []int {
+	if helper.WithoutRazoring {
+		return nil
+	} else if helper.RazoringMargins != nil {
+		return helper.RazoringMargins
+	}
+	return defaultRazoringMargins
+}
+
+// maxFrontierDepth is the furthest from the leaves that any of the margin
+// based pruning is done
+func (helper *SearchHelper) maxFrontierDepth() int {
+	return MaxInt(len(helper.futilityMargins()), MaxInt(len(helper.reverseFutilityMargins()), len(helper.razoringMargins())))
+}
+
+// reverseFutilityCutoff (aka static null move pruning) fails high when the
+// static eval beats beta by so much that the opponent won't be able to catch
+// up in the remaining plies.
+func (helper *SearchHelper) reverseFutilityCutoff(staticEval int, beta int, depthRemaining int) bool {
+	margin := pruningMargin(helper.reverseFutilityMargins(), depthRemaining)
+	if margin.IsEmpty() || IsMate(beta) {
+		return false
+	}
+	return staticEval-margin.Value() >= beta
+}
+
+// canRazor is true when the static eval is so far below alpha that only a
+// capture could help. The node is then checked with the leaf evaluator
+// (quiescence by default) instead of a full search.
+func (helper *SearchHelper) canRazor(staticEval int, alpha int, depthRemaining int) bool {
+	margin := pruningMargin(helper.razoringMargins(), depthRemaining)
+	if margin.IsEmpty() || IsMate(alpha) {
+		return false
+	}
+	return staticEval+margin.Value() <= alpha
+}
+
+// futile is true when quiet moves are unlikely to raise the static eval to
+// alpha before the search reaches the leaves. Captures, promotions and checks
+// are still searched.
+func (helper *SearchHelper) futile(staticEval int, alpha int, depthRemaining int) bool {
+	margin := pruningMargin(helper.futilityMargins(), depthRemaining)
+	if margin.IsEmpty() || IsMate(alpha) {
+		return false
+	}
+	return staticEval+margin.Value() <= alpha
+}
+
+// mateDistanceBounds narrows the window to the scores that are still possible
+// at this ply: being mated right here is the worst and mating with the next
+// move is the best. If a shorter mate was already found, the window is empty.
+func mateDistanceBounds(alpha int, beta int, currentDepth int) (int, int) {
+	return MaxInt(alpha, MateBlackWins()+currentDepth), MinInt(beta, MateWhiteWins()-currentDepth-1)
+}
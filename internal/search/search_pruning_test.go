@@ -0,0 +1,154 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNullMovePruningSearchesFewerMoves(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	with := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5)})
+	without := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5), WithoutNullMovePruning: true})
+
+	fmt.Println("with null move pruning", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+}
+
+func TestLateMoveReductionsSearchesFewerMoves(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	with := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5), WithoutNullMovePruning: true})
+	without := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5), WithoutNullMovePruning: true, WithoutLateMoveReductions: true})
+
+	fmt.Println("with late move reductions", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+}
+
+func TestNullMovePruningSkipsPawnEndgames(t *testing.T) {
+	g := UnwrapReturn(GamestateFromFenString("8/8/4k3/8/2p5/2P5/4K3/8 w - - 0 1"))
+	assert.False(t, hasNonPawnMaterial(g, White))
+
+	g = UnwrapReturn(GamestateFromFenString("8/8/4k3/8/2p5/2P5/4K3/6N1 w - - 0 1"))
+	assert.True(t, hasNonPawnMaterial(g, White))
+	assert.False(t, hasNonPawnMaterial(g, Black))
+}
+
+func TestMateDistancePruning(t *testing.T) {
+	// Ra7 then Rb8#
+	fen := "7k/8/8/8/8/8/R7/1R4K1 w - - 0 1"
+
+	for _, options := range []SearchOptions{
+		{MaxDepth: Some(5), WithoutCheckStandPat: true},
+		{MaxDepth: Some(5), WithoutCheckStandPat: true, WithoutMateDistancePruning: true},
+	} {
+		pv, score, err := Search(fen, options)
+		assert.True(t, IsNil(err), err)
+		assert.Equal(t, 2, MateInN(score), ScoreString(score))
+		assert.Contains(t, []string{"a2a7", "b1b7"}, pv[0].String())
+	}
+
+	with := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5), WithoutCheckStandPat: true})
+	without := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5), WithoutCheckStandPat: true, WithoutMateDistancePruning: true})
+
+	fmt.Println("with mate distance pruning", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+
+	// Mating at ply 3 can't beat a mate that was already found at ply 1
+	alpha, beta := mateDistanceBounds(UnwrapReturn(MateInNScore(1)), Inf, 3)
+	assert.GreaterOrEqual(t, alpha, beta)
+}
+
+func TestMateInOneWhenInCheck(t *testing.T) {
+	// The mated player can't stand pat while in check
+	pv, score, err := Search("6k1/1R6/8/8/8/8/R7/6K1 w - - 0 1", SearchOptions{MaxDepth: Some(3)})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, 1, MateInN(score), ScoreString(score))
+	assert.Equal(t, "a2a8", pv[0].String())
+}
+
+func TestFutilityPruningSearchesFewerMoves(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	options := SearchOptions{MaxDepth: Some(5), WithoutReverseFutilityPruning: true, WithoutRazoring: true}
+	with := countSearchMoves(t, fen, options)
+	options.WithoutFutilityPruning = true
+	without := countSearchMoves(t, fen, options)
+
+	fmt.Println("with futility pruning", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+}
+
+func TestReverseFutilityPruningSearchesFewerMoves(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	options := SearchOptions{MaxDepth: Some(5), WithoutFutilityPruning: true, WithoutRazoring: true}
+	with := countSearchMoves(t, fen, options)
+	options.WithoutReverseFutilityPruning = true
+	without := countSearchMoves(t, fen, options)
+
+	fmt.Println("with reverse futility pruning", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+}
+
+func TestRazoringSearchesFewerMoves(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	options := SearchOptions{MaxDepth: Some(5), WithoutFutilityPruning: true, WithoutReverseFutilityPruning: true}
+	with := countSearchMoves(t, fen, options)
+	options.WithoutRazoring = true
+	without := countSearchMoves(t, fen, options)
+
+	fmt.Println("with razoring", with, "moves, without", without, "moves")
+	assert.Less(t, with, without)
+}
+
+func TestPruningMargins(t *testing.T) {
+	assert.Equal(t, Some(100), pruningMargin([]int{100, 200}, 1))
+	assert.Equal(t, Some(200), pruningMargin([]int{100, 200}, 2))
+	assert.True(t, pruningMargin([]int{100, 200}, 3).IsEmpty())
+	assert.True(t, pruningMargin([]int{100, 200}, 0).IsEmpty())
+
+	// Empty margins turn the pruning off
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+	assert.Equal(t,
+		countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(4), WithoutFutilityPruning: true, WithoutReverseFutilityPruning: true, WithoutRazoring: true}),
+		countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(4), FutilityMargins: []int{}, ReverseFutilityMargins: []int{}, RazoringMargins: []int{}}))
+
+	// Wider margins prune less
+	assert.Less(t,
+		countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(4), ReverseFutilityMargins: []int{50, 100, 150}}),
+		countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(4), ReverseFutilityMargins: []int{500, 1000, 1500}}))
+}
+
+func TestFrontierPruningFindsMates(t *testing.T) {
+	for _, test := range []struct {
+		fen       string
+		depth     int
+		mateIn    int
+		mateMoves []string
+	}{
+		{"6k1/1R6/8/8/8/8/R7/6K1 w - - 0 1", 3, 1, []string{"a2a8"}},
+		{"5b2/3kp2p/4r3/1p6/4n3/p3P1p1/3p1r2/6K1 b - - 1 46", 3, 1, []string{"d2d1q"}},
+		{"7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", 3, 2, []string{"a2a7", "b1b7"}},
+		{"1K6/8/1b6/5k2/1p2p3/8/2q5/n7 b - - 2 2", 4, 2, []string{"c2c7"}},
+	} {
+		for _, options := range []SearchOptions{
+			{},
+			{WithoutFutilityPruning: true},
+			{WithoutReverseFutilityPruning: true},
+			{WithoutRazoring: true},
+			{CreateEvaluator: Some(CreateBasicEvaluator)},
+		} {
+			options.MaxDepth = Some(test.depth)
+			pv, score, err := Search(test.fen, options)
+			assert.True(t, IsNil(err), err)
+			assert.Equal(t, test.mateIn, MateInN(score), "%v %v", test.fen, ScoreString(score))
+			assert.Contains(t, test.mateMoves, pv[0].String(), test.fen)
+		}
+	}
+}
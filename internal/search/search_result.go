@@ -0,0 +1,104 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/dustin/go-humanize"
+)
+
+// SearchResult describes the search once it's done, or after each completed
+// depth, see SearchOptions.OnIteration
+type SearchResult struct {
+	// The best line and its score, the same as the first of Lines
+	PV    []Move
+	Score int
+	// Exact, unless the search was stopped while re-searching outside of the
+	// aspiration window
+	Bound ScoreType
+
+	Depth int
+	// The deepest ply reached, including extensions and quiescence
+	SelDepth int
+	// Nodes visited by this thread, see Nodes
+	Nodes int
+	Time  time.Duration
+	NPS   int
+	// The fraction of transposition table lookups that found a deep enough
+	// entry
+	TTHitRate float64
+
+	// The best MultiPV lines, best first
+	Lines []Pair[int, []Move]
+
+	// Completed for every iteration, see SearchMultiPV for the final result
+	Status SearchStatus
+}
+
+func (r SearchResult) String() string {
+	bound := ""
+	if r.Bound == AlphaFailUpperBound {
+		bound = " (upper bound)"
+	} else if r.Bound == BetaFailLowerBound {
+		bound = " (lower bound)"
+	}
+
+	return fmt.Sprintf("depth %v/%v score %v%v nodes %v (%v nps) %v ms tt %.0f%% pv %v",
+		r.Depth, r.SelDepth, ScoreString(r.Score), bound,
+		humanize.Comma(int64(r.Nodes)), humanize.Comma(int64(r.NPS)),
+		r.Time.Milliseconds(), 100*r.TTHitRate,
+		strings.Join(MapSlice(r.PV, func(m Move) string { return m.String() }), " "))
+}
+
+// startStatistics resets the counters reported in SearchResult
+func (helper *SearchHelper) startStatistics() {
+	helper.nodes = 0
+	helper.selDepth = 0
+	helper.searchStart = time.Now()
+
+	if helper.TranspositionTable != nil {
+		helper.TranspositionTable.NewSearch()
+		helper.ttHitsAtStart, helper.ttProbesAtStart = helper.TranspositionTable.probes()
+	}
+}
+
+func (helper *SearchHelper) searchResult(best iteration, status SearchStatus) SearchResult {
+	result := SearchResult{
+		Bound:    best.bound,
+		Depth:    best.depth,
+		SelDepth: helper.selDepth,
+		Nodes:    helper.nodes,
+		Time:     time.Since(helper.searchStart),
+		Status:   status,
+	}
+
+	if seconds := result.Time.Seconds(); seconds > 0 {
+		result.NPS = int(float64(result.Nodes) / seconds)
+	}
+
+	if helper.TranspositionTable != nil {
+		hits, probes := helper.TranspositionTable.probes()
+		if probes > helper.ttProbesAtStart {
+			result.TTHitRate = float64(hits-helper.ttHitsAtStart) / float64(probes-helper.ttProbesAtStart)
+		}
+	}
+
+	numLines := MinInt(helper.MultiPV.ValueOr(1), len(best.variations))
+	result.Lines = MapSlice(best.variations[:numLines], func(v Pair[int, []SearchMove]) Pair[int, []Move] {
+		return Pair[int, []Move]{
+			First: v.First,
+			Second: MapSlice(v.Second, func(m SearchMove) Move {
+				return m.Move
+			}),
+		}
+	})
+
+	if len(result.Lines) > 0 {
+		result.Score = result.Lines[0].First
+		result.PV = result.Lines[0].Second
+	}
+
+	return result
+}
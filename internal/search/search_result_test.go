@@ -0,0 +1,74 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnIterationReportsEachDepth(t *testing.T) {
+	g, err := game.GamestateFromFenString("r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10")
+	assert.True(t, IsNil(err), err)
+
+	iterations := []SearchResult{}
+	unregister, helper := NewSearchHelper(g, SearchOptions{
+		MaxDepth: Some(4),
+		MultiPV:  Some(2),
+		OnIteration: Some(func(result SearchResult) {
+			iterations = append(iterations, result)
+		}),
+	})
+	defer unregister()
+
+	result, err := helper.Search()
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, Completed, result.Status)
+
+	assert.Equal(t, 4, len(iterations))
+	for i, iteration := range iterations {
+		assert.Equal(t, i+1, iteration.Depth)
+		assert.Equal(t, Completed, iteration.Status)
+		assert.Equal(t, Exact, iteration.Bound)
+		assert.GreaterOrEqual(t, iteration.SelDepth, iteration.Depth)
+
+		assert.Equal(t, 2, len(iteration.Lines))
+		assert.Equal(t, iteration.Lines[0].First, iteration.Score)
+		assert.Equal(t, iteration.Lines[0].Second, iteration.PV)
+
+		assert.GreaterOrEqual(t, iteration.TTHitRate, 0.0)
+		assert.LessOrEqual(t, iteration.TTHitRate, 1.0)
+		if i > 0 {
+			assert.Greater(t, iteration.Nodes, iterations[i-1].Nodes)
+			assert.GreaterOrEqual(t, iteration.Time, iterations[i-1].Time)
+		}
+	}
+
+	last := iterations[len(iterations)-1]
+	assert.Equal(t, last.PV, result.PV)
+	assert.Equal(t, last.Score, result.Score)
+	assert.Equal(t, last.Nodes, result.Nodes)
+	assert.Greater(t, result.NPS, 0)
+	assert.Greater(t, result.TTHitRate, 0.0)
+}
+
+func TestSearchResultStatisticsAreReset(t *testing.T) {
+	g, err := game.GamestateFromFenString("r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{MaxDepth: Some(4)})
+	defer unregister()
+
+	first, err := helper.Search()
+	assert.True(t, IsNil(err), err)
+
+	// Each search only counts its own nodes
+	helper.SetMaxDepth(1)
+	second, err := helper.Search()
+	assert.True(t, IsNil(err), err)
+
+	assert.Less(t, second.Nodes, first.Nodes)
+	assert.Equal(t, 1, second.Depth)
+	assert.LessOrEqual(t, second.SelDepth, first.SelDepth)
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// _maxPastMoves is the capacity of the moves leading to the current node.
+// Deeper lines still work, but allocate.
+const _maxPastMoves = 256
+
+// _maxMoves is more than the legal moves in any position
+const _maxMoves = 256
+
+// plyState holds the buffers for the node being searched at one ply, so that
+// searching a node doesn't allocate. Only one node is searched at each ply at
+// a time: quiescence, null move and singular searches either run before the
+// node's own moves or search the next ply.
+type plyState struct {
+	// The last move made from this ply, see performMove
+	update BoardUpdate
+
+	// The principal variation from this ply, see principalVariation. This is
+	// the row of the ply in the PV triangle.
+	pv []SearchMove
+
+	// Generated by quiescence and isSingular
+	moves []Move
+
+	picker MovePicker
+}
+
+// ply returns the buffers for `ply`, they're created the first time a search
+// reaches it
+func (helper *SearchHelper) ply(ply int) *plyState {
+	for len(helper.plies) <= ply {
+		helper.plies = append(helper.plies, &plyState{
+			pv:    make([]SearchMove, 0, _maxPastMoves),
+			moves: make([]Move, 0, _maxMoves),
+			picker: MovePicker{
+				captures:    make([]Move, 0, _maxMoves),
+				quiets:      make([]Move, 0, _maxMoves),
+				tried:       make([]Move, 0, 16),
+				sorterMoves: make([]Move, 0, 16),
+			},
+		})
+	}
+	return helper.plies[ply]
+}
+
+// rootPast is an empty list of moves with room for the line to each node
+func (helper *SearchHelper) rootPast() []SearchMove {
+	if helper.pastMoves == nil {
+		helper.pastMoves = make([]SearchMove, 0, _maxPastMoves)
+	}
+	return helper.pastMoves[:0]
+}
+
+// performMove makes `move` and returns whether it was legal. It must be undone
+// with undoMove before the next move is made from `ply`.
+func (helper *SearchHelper) performMove(ply int, move Move) (bool, Error) {
+	g := helper.GameState
+	err := g.PerformMove(move, &helper.ply(ply).update)
+	if err.HasError() {
+		return false, err
+	}
+	return !KingIsInCheck(g.Bitboards, g.Enemy()), NilError
+}
+
+func (helper *SearchHelper) undoMove(ply int) Error {
+	return helper.GameState.UndoUpdate(&helper.ply(ply).update)
+}
+
+// principalVariation stores `move` followed by `future` as the best line from
+// `ply`. `future` is the line from the next ply. The result is overwritten by
+// the next node searched at `ply`, so callers copy it.
+func (helper *SearchHelper) principalVariation(ply int, move SearchMove, future []SearchMove) []SearchMove {
+	p := helper.ply(ply)
+	p.pv = append(append(p.pv[:0], move), future...)
+	return p.pv
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+const _middleGameFen = "r3k2r/1bq1bppp/pp2p3/2p1n3/P3PP2/2PBN3/1P1BQ1PP/R4RK1 b kq - 0 16"
+
+func TestAlphaBetaDoesNotAllocate(t *testing.T) {
+	g, err := game.GamestateFromFenString(_middleGameFen)
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{})
+	defer unregister()
+
+	search := func() {
+		helper.nodes = 0
+		_, _, err := helper.alphaBeta(-InitialBounds(), InitialBounds(), 0, 5, helper.rootPast())
+		assert.True(t, IsNil(err), err)
+	}
+
+	// The first search creates the buffers for each ply
+	helper.TranspositionTable = NewTranspositionTable(1 << 20)
+	search()
+
+	// Each run gets an empty table so that it searches the full tree
+	runs := 3
+	tables := []*TranspositionTable{}
+	for i := 0; i < runs+1; i++ {
+		tables = append(tables, NewTranspositionTable(1<<20))
+	}
+
+	allocs := testing.AllocsPerRun(runs, func() {
+		helper.TranspositionTable = tables[0]
+		tables = tables[1:]
+		search()
+	})
+
+	assert.Greater(t, helper.nodes, 1000)
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkAlphaBeta(b *testing.B) {
+	g, err := game.GamestateFromFenString(_middleGameFen)
+	if !IsNil(err) {
+		b.Fatal(err)
+	}
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{})
+	defer unregister()
+
+	search := func() {
+		b.StopTimer()
+		helper.TranspositionTable = NewTranspositionTable(1 << 20)
+		helper.nodes = 0
+		b.StartTimer()
+
+		_, _, err := helper.alphaBeta(-InitialBounds(), InitialBounds(), 0, 6, helper.rootPast())
+		if !IsNil(err) {
+			b.Fatal(err)
+		}
+	}
+
+	// The first search creates the buffers for each ply
+	search()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	nodes := 0
+	for i := 0; i < b.N; i++ {
+		search()
+		nodes += helper.nodes
+	}
+
+	b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+}
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -542,7 +543,7 @@ func timeSearch(t *testing.T, fen string, label string, options SearchOptions) t
 	defer unregisterCounter()
 
 	start := time.Now()
-	_, _, _, err = helper.Search()
+	_, err = helper.Search()
 	elapsed := time.Since(start)
 
 	fmt.Println(label, elapsed.Milliseconds(), "ms", counter.NumMoves(), "moves")
@@ -591,3 +592,72 @@ func TestTimeQuiescence(t *testing.T) {
 
 	assert.Greater(t, nonIterativeStandPat, iterativeStandPat)
 }
+
+func TestSearchCanBeCancelled(t *testing.T) {
+	g, err := game.GamestateFromFenString("r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{MaxDepth: Some(30), Threads: Some(2)})
+	defer unregister()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	result, err := helper.SearchMultiPV(ctx)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, Interrupted, result.Status)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The best line from the last completed iteration is still returned
+	assert.Equal(t, 1, len(result.Lines))
+	assert.Greater(t, result.Depth, 0)
+
+	// The cancelled context doesn't affect the next search
+	helper.SetMaxDepth(2)
+	result, err = helper.SearchMultiPV(context.Background())
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, Completed, result.Status)
+	assert.Equal(t, 1, len(result.Lines))
+	assert.Equal(t, 2, result.Depth)
+}
+
+func TestNodeLimitIsDeterministic(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	search := func(options SearchOptions) ([]Move, int, int, SearchStatus) {
+		g, err := game.GamestateFromFenString(fen)
+		assert.True(t, IsNil(err), err)
+
+		unregister, helper := NewSearchHelper(g, options)
+		defer unregister()
+
+		result, err := helper.SearchMultiPV(context.Background())
+		assert.True(t, IsNil(err), err)
+		assert.Equal(t, 1, len(result.Lines))
+		return result.PV, result.Score, result.Nodes, result.Status
+	}
+
+	options := SearchOptions{MaxDepth: Some(30), MaxNodes: Some(20000)}
+	pv, score, nodes, result := search(options)
+	assert.Equal(t, OutOfTime, result)
+	assert.Equal(t, 20000, nodes)
+
+	for _, options := range []SearchOptions{
+		options,
+		{MaxDepth: Some(30), MaxNodes: Some(20000), Threads: Some(4)},
+	} {
+		otherPV, otherScore, otherNodes, _ := search(options)
+		assert.Equal(t, pv, otherPV)
+		assert.Equal(t, score, otherScore)
+		assert.Equal(t, nodes, otherNodes)
+	}
+
+	// Node limits combine with depth limits
+	_, _, nodes, result = search(SearchOptions{MaxDepth: Some(2), MaxNodes: Some(1000000)})
+	assert.Equal(t, Completed, result)
+	assert.Less(t, nodes, 1000000)
+}
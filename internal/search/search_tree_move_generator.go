@@ -85,10 +85,18 @@ func NewSearchTreeMoveGenerator(
 var _ MoveGen = (*SearchTreeMoveGenerator)(nil)
 var _ game.MoveListener = (*SearchTreeMoveGenerator)(nil)
 
-func (gen *SearchTreeMoveGenerator) AfterMove(move Move) {
+// _nullMoveSearchTree follows null moves, which aren't part of any line. Its
+// positions are evaluated without searching further.
+var _nullMoveSearchTree = &SearchTree{}
+
+func (gen *SearchTreeMoveGenerator) AfterMove(move Move, update *BoardUpdate) {
 	previous := gen.current
 
-	if gen.current != nil {
+	if move == (Move{}) {
+		if gen.current == nil || !gen.current.continueSearching {
+			gen.current = _nullMoveSearchTree
+		}
+	} else if gen.current != nil {
 		nextSearchTree, contains := gen.current.moves[move.String()]
 		if contains {
 			gen.current = nextSearchTree
@@ -98,41 +106,35 @@ func (gen *SearchTreeMoveGenerator) AfterMove(move Move) {
 	gen.history = append(gen.history, previous)
 }
 
-func (gen *SearchTreeMoveGenerator) AfterUndo() {
+func (gen *SearchTreeMoveGenerator) AfterUndo(update *BoardUpdate) {
 	gen.current, gen.history = PopPtr(gen.history)
 }
 
-func (gen *SearchTreeMoveGenerator) generateMoves(g *game.GameState, mode MoveGenerationMode) (func(), MoveGenerationResult, *[]Move, Error) {
-	moves := GetMovesBuffer()
-	cleanup := func() { ReleaseMovesBuffer(moves) }
-
+func (gen *SearchTreeMoveGenerator) generateMoves(g *game.GameState, mode MoveGenerationMode, moves *[]Move) (MoveGenerationResult, Error) {
 	result := AllLegalMoves
 
-	if mode == OnlyCaptures {
-		result = SomeLegalMoves
-		GeneratePseudoCaptures(func(m Move) {
+	*moves = (*moves)[:0]
+	GeneratePseudoMovesForMode(func(m Move) {
+		if gen.current != nil && gen.current.continueSearching {
+			// Perform all moves
 			*moves = append(*moves, m)
-		}, g)
-	} else {
-		GeneratePseudoMoves(func(m Move) {
+		} else if _, contains := gen.current.moves[m.String()]; contains {
 			*moves = append(*moves, m)
-		}, g)
-	}
-
-	if gen.current != nil && gen.current.continueSearching {
-		// Perform all moves
-		return cleanup, result, moves, NilError
-	}
-
-	*moves = FilterSlice(*moves, func(m Move) bool {
-		_, contains := gen.current.moves[m.String()]
-		if !contains {
+		} else {
 			result = SomeLegalMoves
-			return false
 		}
+	}, g, mode)
 
-		return true
-	})
+	return result, NilError
+}
 
-	return cleanup, result, moves, NilError
+func (gen *SearchTreeMoveGenerator) isPseudoLegal(g *game.GameState, move Move) bool {
+	if !IsPseudoLegal(g, move) {
+		return false
+	}
+	if gen.current != nil && gen.current.continueSearching {
+		return true
+	}
+	_, contains := gen.current.moves[move.String()]
+	return contains
 }
@@ -0,0 +1,242 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// SearchTreeNode is a position visited by alphaBeta. Its score is from the
+// perspective of the player to move in the position.
+type SearchTreeNode struct {
+	// The move that lead to this position. "null" for null-move searches and
+	// "qs" where quiescence starts searching the same position.
+	Move           string `json:"move"`
+	Depth          int    `json:"depth"`
+	DepthRemaining int    `json:"depthRemaining"`
+	Alpha          int    `json:"alpha"`
+	Beta           int    `json:"beta"`
+	Score          int    `json:"score"`
+	// How the search of this position finished, using the same labels as the
+	// Debug logger, eg "b-cut", "pv", "a-skip", "sp-b-cut" or "tt"
+	Cutoff       string `json:"cutoff"`
+	InQuiescence bool   `json:"inQuiescence"`
+
+	Children []*SearchTreeNode `json:"children,omitempty"`
+	// Children that weren't recorded because of the recorder's limits
+	Skipped int `json:"skipped,omitempty"`
+
+	pastLength int
+}
+
+// SearchTreeRecorder records the tree explored by the latest root search, see
+// SearchOptions.TreeRecorder. Each iteration and aspiration re-search replaces
+// the previous tree.
+type SearchTreeRecorder struct {
+	// Positions deeper than MaxDepth plies aren't recorded
+	MaxDepth Optional[int]
+	// Stop recording after MaxNodes positions
+	MaxNodes Optional[int]
+
+	Root *SearchTreeNode
+
+	numNodes int
+	// The nodes currently being searched. Nil entries are nodes that weren't
+	// recorded.
+	stack []*SearchTreeNode
+
+	noCopy NoCopy
+}
+
+func NewSearchTreeRecorder(maxDepth Optional[int], maxNodes Optional[int]) *SearchTreeRecorder {
+	return &SearchTreeRecorder{
+		MaxDepth: maxDepth,
+		MaxNodes: maxNodes,
+	}
+}
+
+func (r *SearchTreeRecorder) startRoot(depthRemaining int, alpha int, beta int) {
+	r.Root = &SearchTreeNode{
+		DepthRemaining: depthRemaining,
+		Alpha:          alpha,
+		Beta:           beta,
+		Cutoff:         "root",
+	}
+	r.numNodes = 1
+	r.stack = []*SearchTreeNode{r.Root}
+}
+
+func (r *SearchTreeRecorder) finishRoot(variations []Pair[int, []SearchMove]) {
+	if r.Root == nil {
+		return
+	}
+	r.Root.Score = r.Root.Alpha
+	for _, variation := range variations {
+		r.Root.Score = MaxInt(r.Root.Score, variation.First)
+	}
+	r.stack = nil
+}
+
+func (r *SearchTreeRecorder) parent() *SearchTreeNode {
+	if len(r.stack) == 0 {
+		return nil
+	}
+	return r.stack[len(r.stack)-1]
+}
+
+func (r *SearchTreeRecorder) enter(helper *SearchHelper, alpha int, beta int, currentDepth int, depthRemaining int, past []SearchMove) {
+	parent := r.parent()
+	if parent == nil {
+		r.stack = append(r.stack, nil)
+		return
+	}
+
+	if currentDepth > r.MaxDepth.ValueOr(Inf) || r.numNodes >= r.MaxNodes.ValueOr(Inf) {
+		parent.Skipped++
+		r.stack = append(r.stack, nil)
+		return
+	}
+
+	move := ""
+	if len(past) > 0 {
+		if past[len(past)-1] == nullSearchMove {
+			move = "null"
+		} else {
+			move = past[len(past)-1].Move.String()
+		}
+	}
+	if parent != r.Root && parent.pastLength == len(past) && helper.InQuiescence && !parent.InQuiescence {
+		// Quiescence searches the same position again
+		move = "qs"
+	}
+
+	node := &SearchTreeNode{
+		Move:           move,
+		Depth:          currentDepth,
+		DepthRemaining: depthRemaining,
+		Alpha:          alpha,
+		Beta:           beta,
+		InQuiescence:   helper.InQuiescence,
+		pastLength:     len(past),
+	}
+	parent.Children = append(parent.Children, node)
+	r.numNodes++
+	r.stack = append(r.stack, node)
+}
+
+// cutoff labels how the current node returned early
+func (r *SearchTreeRecorder) cutoff(label string) {
+	if node := r.parent(); node != nil && node != r.Root && node.Cutoff == "" {
+		node.Cutoff = label
+	}
+}
+
+func (r *SearchTreeRecorder) exit(score int) {
+	var node *SearchTreeNode
+	node, r.stack = PopValue(r.stack, nil)
+	if node == nil {
+		return
+	}
+
+	node.Score = score
+	if node.Cutoff == "" {
+		if score >= node.Beta {
+			node.Cutoff = "b-cut"
+		} else if score > node.Alpha {
+			node.Cutoff = "pv"
+		} else {
+			node.Cutoff = "a-skip"
+		}
+	}
+}
+
+// alphaBeta records the node around alphaBetaNode if there is a recorder
+func (helper *SearchHelper) alphaBeta(alpha int, beta int, currentDepth int, depthRemaining int, past []SearchMove) ([]SearchMove, int, Error) {
+	if helper.treeRecorder == nil {
+		return helper.alphaBetaNode(alpha, beta, currentDepth, depthRemaining, past)
+	}
+
+	helper.treeRecorder.enter(helper, alpha, beta, currentDepth, depthRemaining, past)
+	future, score, err := helper.alphaBetaNode(alpha, beta, currentDepth, depthRemaining, past)
+	helper.treeRecorder.exit(score)
+
+	return future, score, err
+}
+
+func (helper *SearchHelper) recordCutoff(label string) {
+	if helper.treeRecorder != nil {
+		helper.treeRecorder.cutoff(label)
+	}
+}
+
+func (r *SearchTreeRecorder) WriteJSON(w io.Writer) Error {
+	if r.Root == nil {
+		return Errorf("no search was recorded")
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return Wrap(encoder.Encode(r.Root))
+}
+
+// WriteDOT writes the tree for Graphviz, eg `dot -Tsvg tree.dot > tree.svg`
+func (r *SearchTreeRecorder) WriteDOT(w io.Writer) Error {
+	if r.Root == nil {
+		return Errorf("no search was recorded")
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph search {\n")
+	b.WriteString("  node [shape=box, fontname=monospace];\n")
+
+	id := 0
+	var writeNode func(node *SearchTreeNode) int
+	writeNode = func(node *SearchTreeNode) int {
+		nodeId := id
+		id++
+
+		label := fmt.Sprintf("%v\\n[%v, %v] %v\\n%v",
+			node.Move, ScoreString(node.Alpha), ScoreString(node.Beta), ScoreString(node.Score), node.Cutoff)
+		if node.Skipped > 0 {
+			label += fmt.Sprintf("\\n(%v skipped)", node.Skipped)
+		}
+
+		style := ""
+		if node.InQuiescence {
+			style = ", style=dashed"
+		}
+		if color, ok := _treeCutoffColors[node.Cutoff]; ok {
+			style += ", color=" + color
+		}
+
+		fmt.Fprintf(&b, "  n%v [label=\"%v\"%v];\n", nodeId, label, style)
+
+		for _, child := range node.Children {
+			childId := writeNode(child)
+			fmt.Fprintf(&b, "  n%v -> n%v;\n", nodeId, childId)
+		}
+		return nodeId
+	}
+	writeNode(r.Root)
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return Wrap(err)
+}
+
+var _treeCutoffColors = map[string]string{
+	"b-cut":      "red",
+	"pv":         "blue",
+	"tt":         "darkgreen",
+	"tt-b-cut":   "darkgreen",
+	"tt-a-cut":   "darkgreen",
+	"sp-b-cut":   "orange",
+	"null-b-cut": "purple",
+	"rfp-b-cut":  "orange",
+	"razor":      "gray",
+	"tb":         "brown",
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordSearchTree(t *testing.T, fen string, depth int, recorder *SearchTreeRecorder) {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{
+		MaxDepth:     Some(depth),
+		TreeRecorder: Some(recorder),
+	})
+	defer unregister()
+
+	_, err = helper.Search()
+	assert.True(t, IsNil(err), err)
+}
+
+func walkSearchTree(node *SearchTreeNode, callback func(node *SearchTreeNode)) {
+	callback(node)
+	for _, child := range node.Children {
+		walkSearchTree(child, callback)
+	}
+}
+
+func TestSearchTreeRecorder(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	recorder := NewSearchTreeRecorder(Empty[int](), Empty[int]())
+	recordSearchTree(t, fen, 3, recorder)
+
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+	legalMoves := []Move{}
+	assert.True(t, IsNil(GenerateLegalMoves(g, &legalMoves)))
+
+	root := recorder.Root
+	assert.Equal(t, 3, root.DepthRemaining)
+
+	// Moves that beat the null window are searched again, see searchMove
+	searchedMoves := map[string]bool{}
+	for _, child := range root.Children {
+		searchedMoves[child.Move] = true
+	}
+	assert.Equal(t, len(legalMoves), len(searchedMoves))
+
+	cutoffs := map[string]int{}
+	quiescence := 0
+	walkSearchTree(root, func(node *SearchTreeNode) {
+		assert.NotEmpty(t, node.Cutoff)
+		cutoffs[node.Cutoff]++
+		if node.InQuiescence {
+			quiescence++
+		}
+	})
+
+	assert.Greater(t, cutoffs["pv"], 0)
+	assert.Greater(t, cutoffs["b-cut"], 0)
+	assert.Greater(t, cutoffs["a-skip"], 0)
+	assert.Greater(t, cutoffs["sp-b-cut"], 0)
+	assert.Greater(t, quiescence, 0)
+
+	buffer := bytes.Buffer{}
+	assert.True(t, IsNil(recorder.WriteJSON(&buffer)))
+
+	decoded := SearchTreeNode{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, len(root.Children), len(decoded.Children))
+	assert.Equal(t, root.Children[0].Move, decoded.Children[0].Move)
+
+	buffer.Reset()
+	assert.True(t, IsNil(recorder.WriteDOT(&buffer)))
+	assert.True(t, strings.HasPrefix(buffer.String(), "digraph search {"))
+	assert.Contains(t, buffer.String(), "n0 -> n1;")
+}
+
+func TestSearchTreeRecorderLimits(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	recorder := NewSearchTreeRecorder(Some(2), Some(100))
+	recordSearchTree(t, fen, 3, recorder)
+
+	numNodes := 0
+	skipped := 0
+	walkSearchTree(recorder.Root, func(node *SearchTreeNode) {
+		numNodes++
+		skipped += node.Skipped
+		assert.LessOrEqual(t, node.Depth, 2)
+	})
+
+	assert.Equal(t, 100, numNodes)
+	assert.Greater(t, skipped, 0)
+
+	// The search is the same whether or not it's recorded
+	pv, score, err := Search(fen, SearchOptions{MaxDepth: Some(3)})
+	assert.True(t, IsNil(err), err)
+	recordedPv, recordedScore, err := Search(fen, SearchOptions{MaxDepth: Some(3), TreeRecorder: Some(NewSearchTreeRecorder(Empty[int](), Empty[int]()))})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, pv, recordedPv)
+	assert.Equal(t, score, recordedScore)
+}
+
+func TestSearchTreeRecorderLabelsNullMoves(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	recorder := NewSearchTreeRecorder(Empty[int](), Empty[int]())
+	recordSearchTree(t, fen, 4, recorder)
+
+	moves := map[string]int{}
+	walkSearchTree(recorder.Root, func(node *SearchTreeNode) {
+		moves[node.Move]++
+	})
+
+	assert.Greater(t, moves["null"], 0)
+	assert.Equal(t, 0, moves["a1a1"])
+}
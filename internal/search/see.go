@@ -0,0 +1,139 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/bitboards"
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// _seeValues are indexed by PieceType. The king is given a large value so
+// that capturing with it into a defended square is always losing.
+var _seeValues = []int{
+	500,
+	300,
+	350,
+	20000,
+	900,
+	100,
+	0,
+}
+
+// _leastValuableFirst is the order attackers join an exchange in SEE.
+var _leastValuableFirst = []PieceType{Pawn, Knight, Bishop, Rook, Queen, King}
+
+func leastValuableAttacker(b *Bitboards, attackers Bitboard, player Player) (Bitboard, PieceType) {
+	for _, pieceType := range _leastValuableFirst {
+		pieces := attackers & b.Players[player].Pieces[pieceType]
+		if pieces != 0 {
+			return pieces.LeastSignificantOne(), pieceType
+		}
+	}
+	return 0, InvalidPiece
+}
+
+// SEE (static exchange evaluation) returns the material the current player
+// wins or loses by playing `move` and then trading off every piece that
+// attacks the destination square, least valuable first. Either side may stop
+// trading when continuing would lose material.
+func SEE(g *GameState, move Move) int {
+	b := g.Bitboards
+	target := move.EndIndex
+
+	occupied := b.Occupied
+
+	var gain [32]int
+	if move.MoveType == EnPassantMove {
+		gain[0] = _seeValues[Pawn]
+		captureIndex := target - PawnPushOffsets[g.Player]
+		occupied &= ^SingleBitboard(captureIndex)
+	} else if move.MoveType == CaptureMove {
+		gain[0] = _seeValues[g.Board[target].PieceType()]
+	}
+
+	attacker := g.Board[move.StartIndex].PieceType()
+	if move.PromotionPiece.HasValue() {
+		attacker = move.PromotionPiece.Value()
+		gain[0] += _seeValues[attacker] - _seeValues[Pawn]
+	}
+
+	// The value of the piece currently standing on `target`
+	onTarget := _seeValues[attacker]
+
+	from := SingleBitboard(move.StartIndex)
+	player := g.Player
+
+	depth := 0
+	for depth+1 < len(gain) {
+		// Remove the piece that just captured, revealing any x-rays behind it
+		occupied &= ^from
+		player = player.Other()
+
+		attackers := AttackersToSquare(b, target, occupied)
+		from, attacker = leastValuableAttacker(b, attackers, player)
+		if from == 0 {
+			break
+		}
+
+		depth++
+		gain[depth] = onTarget - gain[depth-1]
+		onTarget = _seeValues[attacker]
+	}
+
+	for ; depth > 0; depth-- {
+		gain[depth-1] = -MaxInt(-gain[depth-1], gain[depth])
+	}
+
+	return gain[0]
+}
+
+// captureOrder scores a move for ordering. Winning and equal captures are
+// ordered by MVV-LVA (most valuable victim, least valuable attacker) and come
+// before quiet moves, which score 0. Captures that lose material according to
+// SEE come last.
+func captureOrder(g *GameState, move Move) int {
+	if !move.MoveType.Captures() && move.PromotionPiece.IsEmpty() {
+		return 0
+	}
+
+	victim := _seeValues[Pawn]
+	if move.MoveType == CaptureMove {
+		victim = _seeValues[g.Board[move.EndIndex].PieceType()]
+	} else if move.MoveType != EnPassantMove {
+		victim = 0
+	}
+	if move.PromotionPiece.HasValue() {
+		victim += _seeValues[move.PromotionPiece.Value()] - _seeValues[Pawn]
+	}
+
+	attacker := _seeValues[g.Board[move.StartIndex].PieceType()]
+	if attacker > victim {
+		// The capture might lose material if the victim is defended
+		if see := SEE(g, move); see < 0 {
+			return see
+		}
+	}
+
+	return 1 + victim*100 - MinInt(attacker, 1000)
+}
+
+// sortMovesMaxFirst is a stable sort that only scores each move once.
+func sortMovesMaxFirst(moves *[]Move, score func(Move) int) {
+	var buffer [256]int
+	scores := buffer[:0]
+	if len(*moves) > len(buffer) {
+		scores = make([]int, 0, len(*moves))
+	}
+	for _, move := range *moves {
+		scores = append(scores, score(move))
+	}
+
+	// insertion sort, move lists are short and often nearly sorted
+	for i := 1; i < len(*moves); i++ {
+		move, moveScore := (*moves)[i], scores[i]
+		j := i
+		for ; j > 0 && scores[j-1] < moveScore; j-- {
+			(*moves)[j], scores[j] = (*moves)[j-1], scores[j-1]
+		}
+		(*moves)[j], scores[j] = move, moveScore
+	}
+}
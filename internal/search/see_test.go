@@ -0,0 +1,86 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/cricklet/chessgo/internal/bitboards"
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttackersToSquare(t *testing.T) {
+	// a queen & bishop battery on the diagonal, rooks on either side of the d-file
+	g, err := game.GamestateFromFenString("3r3k/8/8/3p4/8/1Q2N3/B7/3R2K1 w - - 0 1")
+	assert.True(t, IsNil(err), err)
+
+	target := BoardIndexFromString("d5")
+
+	attackers := AttackersToSquare(g.Bitboards, target, g.Bitboards.Occupied)
+	assert.Equal(t, BitboardWithAllLocationsSet([]string{"d8", "b3", "e3", "d1"}), attackers)
+
+	// once the queen has captured, the bishop behind it x-rays through
+	occupied := g.Bitboards.Occupied & ^SingleBitboard(BoardIndexFromString("b3"))
+	attackers = AttackersToSquare(g.Bitboards, target, occupied)
+	assert.Equal(t, BitboardWithAllLocationsSet([]string{"d8", "a2", "e3", "d1"}), attackers)
+
+	// the black rook is blocked by the pawn on d5 until it's removed
+	occupied &= ^SingleBitboard(target)
+	attackers = AttackersToSquare(g.Bitboards, BoardIndexFromString("d4"), occupied)
+	assert.Equal(t, BitboardWithAllLocationsSet([]string{"d8", "d1"}), attackers)
+}
+
+func TestSEE(t *testing.T) {
+	type testCase struct {
+		fen      string
+		move     string
+		moveType MoveType
+		expected int
+	}
+
+	cases := []testCase{
+		// undefended pawn
+		{"4k3/8/8/3p4/8/8/8/3RK3 w - - 0 1", "d1d5", CaptureMove, 100},
+		// pawn defended by a pawn
+		{"4k3/8/4p3/3p4/8/8/8/3RK3 w - - 0 1", "d1d5", CaptureMove, 100 - 500},
+		// the second rook backs up the first via an x-ray
+		{"3rk3/8/8/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", CaptureMove, 100},
+		{"3rk3/3r4/8/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", CaptureMove, 100 - 500},
+		{"4k3/2n5/8/3r4/8/8/8/3QK3 w - - 0 1", "d1d5", CaptureMove, 500 - 900},
+		{"4k3/8/4q3/3r4/4P3/8/8/4K3 w - - 0 1", "e4d5", CaptureMove, 500 - 100},
+		// the defender won't recapture if it loses more
+		{"4k3/8/4q3/3r4/4P3/8/8/3RK3 w - - 0 1", "e4d5", CaptureMove, 500},
+		// the king can't recapture a defended piece
+		{"8/8/2k5/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", CaptureMove, 100},
+		{"8/8/2k5/3p4/8/8/8/3RK3 w - - 0 1", "d1d5", CaptureMove, 100 - 500},
+		// en passant
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", EnPassantMove, 100},
+	}
+
+	for _, c := range cases {
+		g, err := game.GamestateFromFenString(c.fen)
+		assert.True(t, IsNil(err), err)
+
+		move := MoveFromString(c.move, c.moveType)
+		assert.Equal(t, c.expected, SEE(g, move), fmt.Sprint(c.fen, " ", c.move))
+	}
+}
+
+func TestCaptureOrder(t *testing.T) {
+	g, err := game.GamestateFromFenString("4k3/8/4p3/1q1p4/2P5/8/8/3RK3 w - - 0 1")
+	assert.True(t, IsNil(err), err)
+
+	moves := []Move{
+		MoveFromString("d1d5", CaptureMove),
+		MoveFromString("e1f2", QuietMove),
+		MoveFromString("c4d5", CaptureMove),
+		MoveFromString("c4b5", CaptureMove),
+	}
+	sortMovesMaxFirst(&moves, func(move Move) int {
+		return captureOrder(g, move)
+	})
+
+	// the queen is the most valuable victim, the rook capture loses the exchange
+	assert.Equal(t, "c4b5, c4d5, e1f2, d1d5", ConcatStringify(moves))
+}
@@ -0,0 +1,156 @@
+package search
+
+import (
+	"math/rand"
+	"time"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// MaxSkillLevel plays at full strength
+const MaxSkillLevel = 20
+
+// Skill weakens the search so that it can be played against. Lower levels
+// search shallower, misjudge positions and sometimes play one of the worse
+// root lines.
+type Skill struct {
+	// From 0 (weakest) to MaxSkillLevel
+	Level int
+
+	// Seeds the eval noise and the choice of root lines, random by default
+	Seed Optional[int64]
+}
+
+// _skillElos are the ratings of each level below MaxSkillLevel, measured with
+// `go run ./cmd/elo calibrateSkill`, see data/skill_calibration.json. Each
+// level played 40 games against the next one and level 0 is anchored at 500,
+// so the ratings are relative to each other rather than to rated players.
+var _skillElos = [MaxSkillLevel]int{
+	500, 553, 670, 687, 902, 1019, 1288, 1314, 1542, 1577,
+	1768, 1777, 1924, 1959, 2067, 2102, 2293, 2319, 2436, 2553,
+}
+
+var MinSkillElo = _skillElos[0]
+var MaxSkillElo = _skillElos[MaxSkillLevel-1]
+
+// SkillFromElo returns the strongest level rated at or below `elo`, see
+// _skillElos
+func SkillFromElo(elo int) Skill {
+	level := 0
+	for i, levelElo := range _skillElos {
+		if levelElo <= elo {
+			level = i
+		}
+	}
+	return Skill{Level: level}
+}
+
+func (s Skill) enabled() bool {
+	return s.Level < MaxSkillLevel
+}
+
+func (s Skill) maxDepth() int {
+	return 1 + s.Level/2
+}
+
+func (s Skill) maxNodes() int {
+	return 1000 << (s.Level / 2)
+}
+
+// evalNoise is the most that the noise can change an evaluation by
+func (s Skill) evalNoise() int {
+	return 10 * (MaxSkillLevel - s.Level)
+}
+
+// skillMultiPV is the number of root lines a weakened search chooses from
+const skillMultiPV = 4
+
+func (helper *SearchHelper) skill() Optional[Skill] {
+	if helper.Skill.HasValue() && helper.Skill.Value().enabled() {
+		return helper.Skill
+	}
+	return Empty[Skill]()
+}
+
+// skillRandom is created on the first weakened search. Its first value seeds
+// the eval noise, which stays the same for the helper's lifetime so that the
+// transposition table doesn't mix evaluations with different noise.
+func (helper *SearchHelper) skillRandom() *rand.Rand {
+	if helper.skillRand == nil {
+		seed := helper.Skill.Value().Seed.ValueOr(time.Now().UnixNano())
+		helper.skillRand = rand.New(rand.NewSource(seed))
+		helper.skillNoiseSeed = helper.skillRand.Uint64()
+	}
+	return helper.skillRand
+}
+
+func (helper *SearchHelper) maxDepth() int {
+	maxDepth := helper.MaxDepth.ValueOr(defaultMaxDepth)
+	if skill := helper.skill(); skill.HasValue() {
+		maxDepth = MinInt(maxDepth, skill.Value().maxDepth())
+	}
+	return maxDepth
+}
+
+// maxNodes is the smaller of MaxNodes and the Skill's node limit
+func (helper *SearchHelper) maxNodes() Optional[int] {
+	skill := helper.skill()
+	if skill.IsEmpty() {
+		return helper.MaxNodes
+	}
+	return Some(MinInt(helper.MaxNodes.ValueOr(Inf), skill.Value().maxNodes()))
+}
+
+// skillNoise is added to the evaluation for `player`. It's derived from the
+// position so that the same position always gets the same noise.
+func (helper *SearchHelper) skillNoise(player Player) int {
+	skill := helper.skill()
+	if skill.IsEmpty() {
+		return 0
+	}
+	helper.skillRandom()
+
+	// The splitmix64 finalizer
+	h := helper.GameState.ZobristHash() ^ helper.skillNoiseSeed
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	h = h ^ (h >> 31)
+
+	amplitude := skill.Value().evalNoise()
+	noise := int(h%uint64(2*amplitude+1)) - amplitude
+	if player == Black {
+		return -noise
+	}
+	return noise
+}
+
+// pickSkillLine moves the line that a weakened search plays to the front.
+// The lines are sorted best first and the choice is between the first
+// skillMultiPV. Like Stockfish, weaker levels give worse lines a bigger
+// random push.
+func (helper *SearchHelper) pickSkillLine(variations []Pair[int, []SearchMove]) []Pair[int, []SearchMove] {
+	skill := helper.skill()
+	if skill.IsEmpty() || len(variations) <= 1 {
+		return variations
+	}
+
+	random := helper.skillRandom()
+	numLines := MinInt(helper.numLines(), len(variations))
+
+	top := variations[0].First
+	delta := MinInt(top-variations[numLines-1].First, 100)
+	weakness := 120 - 2*skill.Value().Level
+
+	best := 0
+	bestScore := -Inf
+	for i, v := range variations[:numLines] {
+		push := (weakness*(top-v.First) + delta*random.Intn(weakness)) / 128
+		if v.First+push >= bestScore {
+			best = i
+			bestScore = v.First + push
+		}
+	}
+
+	result := append([]Pair[int, []SearchMove]{variations[best]}, variations[:best]...)
+	return append(result, variations[best+1:]...)
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkillFromElo(t *testing.T) {
+	assert.Equal(t, 0, SkillFromElo(0).Level)
+	assert.Equal(t, 0, SkillFromElo(MinSkillElo).Level)
+	assert.Equal(t, MaxSkillLevel-1, SkillFromElo(MaxSkillElo).Level)
+	assert.Equal(t, MaxSkillLevel-1, SkillFromElo(3000).Level)
+
+	previous := 0
+	for elo := MinSkillElo; elo <= MaxSkillElo; elo += 10 {
+		level := SkillFromElo(elo).Level
+		assert.GreaterOrEqual(t, level, previous, elo)
+		previous = level
+	}
+}
+
+func TestSkillNoise(t *testing.T) {
+	g, err := game.GamestateFromFenString("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{Skill: Some(Skill{Level: 0, Seed: Some[int64](1)})})
+	defer unregister()
+
+	noise := helper.skillNoise(White)
+	assert.Equal(t, noise, helper.skillNoise(White))
+	assert.Equal(t, -noise, helper.skillNoise(Black))
+	assert.LessOrEqual(t, AbsDiff(noise, 0), Skill{Level: 0}.evalNoise())
+
+	helper.Skill = Some(Skill{Level: MaxSkillLevel})
+	assert.Equal(t, 0, helper.skillNoise(White))
+}
+
+func TestSkillLimitsTheSearch(t *testing.T) {
+	g, err := game.GamestateFromFenString("r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{
+		Skill: Some(Skill{Level: 4, Seed: Some[int64](1)}),
+	})
+	defer unregister()
+
+	result, err := helper.SearchMultiPV(context.Background())
+	assert.True(t, IsNil(err), err)
+	assert.LessOrEqual(t, result.Depth, Skill{Level: 4}.maxDepth())
+	assert.LessOrEqual(t, helper.Nodes(), Skill{Level: 4}.maxNodes())
+}
+
+func TestSkillChoosesWorseMoves(t *testing.T) {
+	fen := "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10"
+
+	moves := map[string]bool{}
+	for seed := int64(0); seed < 10; seed++ {
+		pv, _, err := Search(fen, SearchOptions{
+			Skill: Some(Skill{Level: 0, Seed: Some(seed)}),
+		})
+		assert.True(t, IsNil(err), err)
+		moves[pv[0].String()] = true
+	}
+	assert.Greater(t, len(moves), 1)
+
+	// Searches with the same seed are repeatable
+	options := SearchOptions{Skill: Some(Skill{Level: 0, Seed: Some[int64](3)})}
+	pv1, _, _ := Search(fen, options)
+	pv2, _, _ := Search(fen, options)
+	assert.Equal(t, pv1[0], pv2[0])
+
+	// Stronger levels still take a free queen
+	for seed := int64(0); seed < 10; seed++ {
+		pv, _, err := Search("4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", SearchOptions{
+			Skill: Some(Skill{Level: 10, Seed: Some(seed)}),
+		})
+		assert.True(t, IsNil(err), err)
+		assert.Equal(t, "d2d5", pv[0].String(), seed)
+	}
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+type Outcome int
+
+const (
+	Loss Outcome = iota - 1
+	Draw
+	Win
+)
+
+// TablebaseResult is the perfect-play result for the player to move
+type TablebaseResult struct {
+	Outcome Outcome
+	// Plies until mate, zero for draws
+	DistanceToMate int
+}
+
+// Tablebase looks up endgames with few pieces left, see the tablebase package.
+// Probe must be safe to call from several threads at once.
+type Tablebase interface {
+	Probe(g *GameState) Optional[TablebaseResult]
+}
+
+// Score converts the result into a mate score relative to the search root,
+// see IsMate
+func (r TablebaseResult) Score(currentDepth int) int {
+	switch r.Outcome {
+	case Win:
+		return MateWhiteWins() - currentDepth - r.DistanceToMate
+	case Loss:
+		return MateBlackWins() + currentDepth + r.DistanceToMate
+	}
+	return 0
+}
+
+// Better is true if `r` is a better result for the player to move than `o`
+func (r TablebaseResult) Better(o TablebaseResult) bool {
+	return r.Score(0) > o.Score(0)
+}
+
+// FromEnemy converts the result after a move into the result before it
+func (r TablebaseResult) FromEnemy() TablebaseResult {
+	if r.Outcome == Draw {
+		return r
+	}
+	return TablebaseResult{Outcome: -r.Outcome, DistanceToMate: r.DistanceToMate + 1}
+}
+
+// probeTablebase returns the score of the current position if it is in the
+// tablebase
+func (helper *SearchHelper) probeTablebase(currentDepth int) Optional[int] {
+	if helper.Tablebase.IsEmpty() {
+		return Empty[int]()
+	}
+
+	result := helper.Tablebase.Value().Probe(helper.GameState)
+	if result.IsEmpty() {
+		return Empty[int]()
+	}
+
+	if result.Value().Outcome == Draw {
+		// Scored like any other draw, so Contempt applies
+		return Some(helper.drawScore(currentDepth))
+	}
+	return Some(result.Value().Score(currentDepth))
+}
+
+// filterTablebaseRootMoves keeps only the root moves that lead to the best
+// tablebase result. The search then decides between them, eg which of the
+// winning moves mates fastest.
+func (helper *SearchHelper) filterTablebaseRootMoves(moves *[]Move) Error {
+	if helper.Tablebase.IsEmpty() || helper.Tablebase.Value().Probe(helper.GameState).IsEmpty() {
+		return NilError
+	}
+
+	results := []Optional[TablebaseResult]{}
+	best := Empty[TablebaseResult]()
+
+	for _, move := range *moves {
+		undo, legal, err := performMoveAndReturnLegality(helper.GameState, move)
+		if err.HasError() {
+			return err
+		}
+
+		result := Empty[TablebaseResult]()
+		if legal {
+			result = helper.Tablebase.Value().Probe(helper.GameState)
+		}
+
+		err = undo()
+		if err.HasError() {
+			return err
+		}
+
+		if result.HasValue() {
+			// The result after the move is for the enemy
+			result = Some(result.Value().FromEnemy())
+
+			if best.IsEmpty() || result.Value().Better(best.Value()) {
+				best = result
+			}
+		}
+		results = append(results, result)
+	}
+
+	if best.IsEmpty() {
+		return NilError
+	}
+
+	// Moves that leave the tablebase are only dropped if there is a known win
+	filtered := (*moves)[:0]
+	for i, move := range *moves {
+		keep := results[i].IsEmpty() && best.Value().Outcome != Win
+		if keep || results[i].HasValue() && results[i].Value().Outcome == best.Value().Outcome {
+			filtered = append(filtered, move)
+		}
+	}
+	*moves = filtered
+
+	return NilError
+}
@@ -0,0 +1,196 @@
+package search
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+const (
+	// Reserved for communication with the GUI so we don't lose on time
+	moveOverhead = 30 * time.Millisecond
+
+	// When the number of moves until the next time control is unknown
+	defaultMovesToGo = 30
+
+	// The hard limit allows a search to go this far past the soft limit
+	hardLimitFactor = 4
+
+	// Once the best move has been the same for this many iterations, the
+	// search is allowed to stop at half the soft limit
+	stableBestMoveIterations = 3
+
+	// The most time to spend when there is only one legal move
+	singleMoveTime = 10 * time.Millisecond
+)
+
+// TimeManager decides how long to think in a timed game. The soft limit is
+// checked between iterations of iterative deepening. The hard limit stops the
+// search even if an iteration is unfinished.
+//
+// While pondering, neither limit applies. The clock starts on PonderHit and
+// the search keeps everything it has found so far.
+type TimeManager struct {
+	SoftLimit time.Duration
+	HardLimit time.Duration
+
+	numLegalMoves int
+
+	// Set for "go movetime", the search runs until the hard limit
+	fixedTime bool
+
+	// PonderHit is called from another goroutine than the search
+	mutex       sync.Mutex
+	pondering   bool
+	stopped     bool
+	onHardLimit func()
+	timer       *time.Timer
+
+	start            time.Time
+	bestMove         Optional[Move]
+	stableIterations int
+}
+
+func NewTimeManager(clock Clock, player Player, numLegalMoves int) *TimeManager {
+	available := clock.Remaining[player] - moveOverhead
+	if available < 0 {
+		available = 0
+	}
+
+	movesToGo := MaxInt(1, clock.MovesToGo.ValueOr(defaultMovesToGo))
+
+	soft := available/time.Duration(movesToGo) + clock.Increment[player]*3/4
+	hard := soft * hardLimitFactor
+	if hard > available*8/10 {
+		hard = available * 8 / 10
+	}
+	if numLegalMoves <= 1 && hard > singleMoveTime {
+		hard = singleMoveTime
+	}
+	if soft > hard {
+		soft = hard
+	}
+
+	return &TimeManager{
+		SoftLimit:     soft,
+		HardLimit:     hard,
+		numLegalMoves: numLegalMoves,
+	}
+}
+
+// NewMoveTimeManager searches for exactly `duration`.
+func NewMoveTimeManager(duration time.Duration) *TimeManager {
+	return &TimeManager{
+		SoftLimit: duration,
+		HardLimit: duration,
+		fixedTime: true,
+	}
+}
+
+// StartPondering must be called before the search starts. The time limits
+// won't apply until PonderHit.
+func (t *TimeManager) StartPondering() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.pondering = true
+}
+
+// PonderHit is called when the opponent plays the move we were pondering on.
+// From now on, the search is timed.
+func (t *TimeManager) PonderHit() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.pondering {
+		return
+	}
+	t.pondering = false
+
+	if t.onHardLimit != nil && !t.stopped {
+		t.startLocked()
+	}
+}
+
+func (t *TimeManager) Pondering() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.pondering
+}
+
+func (t *TimeManager) Elapsed() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.elapsedLocked()
+}
+
+func (t *TimeManager) elapsedLocked() time.Duration {
+	if t.pondering || t.start.IsZero() {
+		return 0
+	}
+	return time.Since(t.start)
+}
+
+func (t *TimeManager) startLocked() {
+	t.start = time.Now()
+	t.timer = time.AfterFunc(t.HardLimit, t.onHardLimit)
+}
+
+// startTimer resets the clock and calls `onHardLimit` once the hard limit has
+// passed. The returned function cancels the timer.
+func (t *TimeManager) startTimer(onHardLimit func()) func() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.bestMove = Empty[Move]()
+	t.stableIterations = 0
+	t.stopped = false
+	t.start = time.Time{}
+	t.onHardLimit = onHardLimit
+
+	if !t.pondering {
+		t.startLocked()
+	}
+
+	return func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		t.stopped = true
+		if t.timer != nil {
+			t.timer.Stop()
+			t.timer = nil
+		}
+	}
+}
+
+// shouldStop is called after each completed iteration with its best move.
+func (t *TimeManager) shouldStop(bestMove Move, score int) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.pondering || t.fixedTime {
+		return false
+	}
+
+	if t.numLegalMoves <= 1 || IsMate(score) {
+		return true
+	}
+
+	if t.bestMove == Some(bestMove) {
+		t.stableIterations++
+	} else {
+		t.stableIterations = 0
+	}
+	t.bestMove = Some(bestMove)
+
+	limit := t.SoftLimit
+	if t.stableIterations >= stableBestMoveIterations {
+		limit /= 2
+	}
+
+	return t.elapsedLocked() >= limit
+}
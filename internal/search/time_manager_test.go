@@ -0,0 +1,129 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeManagerLimits(t *testing.T) {
+	clock := Clock{
+		Remaining: [2]time.Duration{60 * time.Second, 10 * time.Second},
+		Increment: [2]time.Duration{time.Second, 0},
+	}
+
+	white := NewTimeManager(clock, White, 20)
+	assert.Greater(t, white.SoftLimit, 2*time.Second)
+	assert.Less(t, white.SoftLimit, 4*time.Second)
+	assert.Equal(t, white.SoftLimit*hardLimitFactor, white.HardLimit)
+
+	black := NewTimeManager(clock, Black, 20)
+	assert.Less(t, black.SoftLimit, white.SoftLimit)
+
+	// With one move until the time control, most of the clock can be used
+	// but never all of it
+	clock.MovesToGo = Some(1)
+	white = NewTimeManager(clock, White, 20)
+	assert.Greater(t, white.HardLimit, 30*time.Second)
+	assert.Less(t, white.HardLimit, 60*time.Second)
+	assert.LessOrEqual(t, white.SoftLimit, white.HardLimit)
+
+	// Only one legal move
+	white = NewTimeManager(clock, White, 1)
+	assert.LessOrEqual(t, white.HardLimit, singleMoveTime)
+
+	// Out of time
+	clock.Remaining[White] = 0
+	white = NewTimeManager(clock, White, 20)
+	assert.Equal(t, time.Duration(0), white.HardLimit)
+}
+
+func TestTimeManagerStopsEarly(t *testing.T) {
+	clock := Clock{Remaining: [2]time.Duration{time.Hour, time.Hour}}
+	e2e4 := MoveFromString("e2e4", QuietMove)
+	d2d4 := MoveFromString("d2d4", QuietMove)
+
+	manager := NewTimeManager(clock, White, 20)
+	manager.SoftLimit = 100 * time.Millisecond
+	manager.startTimer(func() {})()
+
+	assert.False(t, manager.shouldStop(e2e4, 10))
+	assert.True(t, manager.shouldStop(e2e4, MateWhiteWins()-3))
+
+	// Stable best moves halve the soft limit
+	manager.startTimer(func() {})()
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, manager.shouldStop(d2d4, 10))
+	for i := 0; i < stableBestMoveIterations; i++ {
+		assert.False(t, manager.shouldStop(e2e4, 10))
+	}
+	assert.True(t, manager.shouldStop(e2e4, 10))
+
+	manager = NewTimeManager(clock, White, 1)
+	assert.True(t, manager.shouldStop(e2e4, 10))
+}
+
+func TestTimeManagerSearch(t *testing.T) {
+	clock := Clock{Remaining: [2]time.Duration{time.Second, time.Second}}
+
+	// Only one legal move, the search shouldn't think about it
+	g, err := game.GamestateFromFenString("7k/8/8/8/8/8/6r1/r6K w - - 0 1")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, SearchOptions{MaxDepth: Some(20)})
+	defer unregister()
+
+	helper.TimeManager = NewTimeManager(clock, White, 1)
+
+	start := time.Now()
+	result, err := helper.Search()
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, "h1g2", result.PV[0].String())
+	assert.Equal(t, 1, result.Depth)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// The hard limit stops deep searches
+	g, err = game.GamestateFromFenString("r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10")
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper = NewSearchHelper(g, SearchOptions{MaxDepth: Some(20)})
+	defer unregister()
+
+	helper.TimeManager = NewTimeManager(clock, White, 40)
+
+	start = time.Now()
+	_, err = helper.Search()
+	assert.True(t, IsNil(err), err)
+	assert.Less(t, time.Since(start), helper.TimeManager.HardLimit+200*time.Millisecond)
+}
+
+func TestTimeManagerPonder(t *testing.T) {
+	clock := Clock{Remaining: [2]time.Duration{time.Second, time.Second}}
+	e2e4 := MoveFromString("e2e4", QuietMove)
+
+	manager := NewTimeManager(clock, White, 20)
+	manager.StartPondering()
+
+	hardLimit := make(chan bool, 1)
+	stop := manager.startTimer(func() { hardLimit <- true })
+	defer stop()
+
+	// No limits while pondering
+	time.Sleep(manager.HardLimit + 10*time.Millisecond)
+	assert.Equal(t, time.Duration(0), manager.Elapsed())
+	assert.False(t, manager.shouldStop(e2e4, MateWhiteWins()-1))
+	assert.Empty(t, hardLimit)
+
+	manager.PonderHit()
+	assert.False(t, manager.Pondering())
+	assert.True(t, manager.shouldStop(e2e4, MateWhiteWins()-1))
+
+	select {
+	case <-hardLimit:
+	case <-time.After(manager.HardLimit + time.Second):
+		assert.Fail(t, "hard limit should have been reached")
+	}
+}
@@ -2,7 +2,7 @@ package search
 
 import (
 	"fmt"
-	"math"
+	"sync/atomic"
 
 	. "github.com/cricklet/chessgo/internal/helpers"
 	"github.com/dustin/go-humanize"
@@ -22,23 +22,94 @@ type CachedEvaluation struct {
 	Score       int
 	ScoreType   ScoreType
 	ZobristHash uint64
+	BestMove    Optional[Move]
+	Generation  uint8
+}
+
+// transpositionEntry stores a CachedEvaluation packed into `data`. The key is
+// stored xor'd with the data so that searches running on other goroutines can
+// read and write entries without locking. If two writes race, the entry will
+// fail to verify and is treated as a miss.
+type transpositionEntry struct {
+	key  uint64
+	data uint64
+}
+
+const (
+	_ttMoveBits       = 18
+	_ttDepthShift     = _ttMoveBits
+	_ttScoreTypeShift = _ttDepthShift + 8
+	_ttGenShift       = _ttScoreTypeShift + 2
+	_ttScoreShift     = _ttGenShift + 8
+	_ttScoreOffset    = 1 << 27
+)
+
+func encodeMove(move Optional[Move]) uint64 {
+	if move.IsEmpty() {
+		return 0
+	}
+	m := move.Value()
+	result := uint64(m.StartIndex) | uint64(m.EndIndex)<<6 | uint64(m.MoveType)<<12
+	if m.PromotionPiece.HasValue() {
+		result |= uint64(m.PromotionPiece.Value()+1) << 14
+	}
+	return result | 1<<17
+}
+
+func decodeMove(data uint64) Optional[Move] {
+	if data&(1<<17) == 0 {
+		return Empty[Move]()
+	}
+	m := Move{
+		StartIndex: int(data & 0x3f),
+		EndIndex:   int((data >> 6) & 0x3f),
+		MoveType:   MoveType((data >> 12) & 0x3),
+	}
+	if promotion := (data >> 14) & 0x7; promotion != 0 {
+		m.PromotionPiece = Some(PieceType(promotion - 1))
+	}
+	return Some(m)
+}
+
+func encodeEvaluation(v CachedEvaluation) uint64 {
+	return encodeMove(v.BestMove) |
+		uint64(MaxInt(0, MinInt(255, v.Depth)))<<_ttDepthShift |
+		uint64(v.ScoreType)<<_ttScoreTypeShift |
+		uint64(v.Generation)<<_ttGenShift |
+		uint64(v.Score+_ttScoreOffset)<<_ttScoreShift
+}
+
+func decodeEvaluation(hash uint64, data uint64) CachedEvaluation {
+	return CachedEvaluation{
+		Depth:       int((data >> _ttDepthShift) & 0xff),
+		Score:       int(data>>_ttScoreShift) - _ttScoreOffset,
+		ScoreType:   ScoreType((data >> _ttScoreTypeShift) & 0x3),
+		ZobristHash: hash,
+		BestMove:    decodeMove(data),
+		Generation:  uint8((data >> _ttGenShift) & 0xff),
+	}
 }
 
 type TranspositionTable struct {
 	Size        int
-	Cache       []CachedEvaluation
-	Hits        int
-	Collisions  int
-	DepthTooLow int
-	Misses      int
+	Hits        atomic.Int64
+	Collisions  atomic.Int64
+	DepthTooLow atomic.Int64
+	Misses      atomic.Int64
+
+	entries    []transpositionEntry
+	generation atomic.Uint32
+
+	noCopy NoCopy
 }
 
-var DefaultTranspositionTableSize = int(math.Pow(2, 26))
+var DefaultTranspositionTableSizeInBytes = 32 * 1024 * 1024
 
-func NewTranspositionTable(size int) *TranspositionTable {
+func NewTranspositionTable(sizeInBytes int) *TranspositionTable {
+	size := MaxInt(1, sizeInBytes/16 /* bytes per entry */)
 	return &TranspositionTable{
-		Size:  size,
-		Cache: make([]CachedEvaluation, size),
+		Size:    size,
+		entries: make([]transpositionEntry, size),
 	}
 }
 
@@ -47,7 +118,7 @@ var _defaultTranspositionTable *TranspositionTable
 
 func DefaultTranspositionTable() *TranspositionTable {
 	if _defaultTranspositionTable == nil {
-		_defaultTranspositionTable = NewTranspositionTable(DefaultTranspositionTableSize)
+		_defaultTranspositionTable = NewTranspositionTable(DefaultTranspositionTableSizeInBytes)
 		_numTranspositionTables++
 	}
 	return _defaultTranspositionTable
@@ -55,35 +126,112 @@ func DefaultTranspositionTable() *TranspositionTable {
 
 func (t *TranspositionTable) Stats() string {
 	return fmt.Sprintf("hits: %v, collisions: %v, depth too low: %v, misses: %v (%v))",
-		humanize.Comma(int64(t.Hits)), humanize.Comma(int64(t.Collisions)), humanize.Comma(int64(t.DepthTooLow)), humanize.Comma(int64(t.Misses)),
+		humanize.Comma(t.Hits.Load()), humanize.Comma(t.Collisions.Load()), humanize.Comma(t.DepthTooLow.Load()), humanize.Comma(t.Misses.Load()),
 		_numTranspositionTables,
 	)
 }
 
-func (t *TranspositionTable) Get(hash uint64, depth int) Optional[CachedEvaluation] {
-	i := hash % uint64(t.Size)
-	v := t.Cache[i]
-	if v.ZobristHash == hash {
+// probes returns how many lookups found a deep enough entry and how many
+// lookups there were in total
+func (t *TranspositionTable) probes() (int64, int64) {
+	hits := t.Hits.Load()
+	return hits, hits + t.Collisions.Load() + t.DepthTooLow.Load() + t.Misses.Load()
+}
+
+// NewSearch ages the entries from previous searches. Old entries are still
+// returned by Get, but they are the first to be replaced by Put.
+func (t *TranspositionTable) NewSearch() {
+	t.generation.Add(1)
+}
+
+func (t *TranspositionTable) load(hash uint64) Optional[CachedEvaluation] {
+	entry := &t.entries[hash%uint64(t.Size)]
+	data := atomic.LoadUint64(&entry.data)
+	key := atomic.LoadUint64(&entry.key)
+	if data == 0 || key^data != hash {
+		return Empty[CachedEvaluation]()
+	}
+	return Some(decodeEvaluation(hash, data))
+}
+
+func (t *TranspositionTable) loadAny(hash uint64) Optional[CachedEvaluation] {
+	entry := &t.entries[hash%uint64(t.Size)]
+	data := atomic.LoadUint64(&entry.data)
+	key := atomic.LoadUint64(&entry.key)
+	if data == 0 {
+		return Empty[CachedEvaluation]()
+	}
+	return Some(decodeEvaluation(key^data, data))
+}
+
+// Get returns the cached evaluation if it was searched at least as deep as
+// `depth`. It also returns the best move for the position regardless of depth
+// so that it can be used for move ordering.
+func (t *TranspositionTable) Get(hash uint64, depth int) (Optional[CachedEvaluation], Optional[Move]) {
+	cached := t.load(hash)
+	if cached.HasValue() {
+		v := cached.Value()
 		if v.Depth >= depth {
-			t.Hits++
-			return Some(v)
+			t.Hits.Add(1)
+			return Some(v), v.BestMove
 		} else {
-			t.DepthTooLow++
+			t.DepthTooLow.Add(1)
+			return Empty[CachedEvaluation](), v.BestMove
 		}
-	} else if v.ScoreType != NoneType {
-		t.Collisions++
+	} else if t.loadAny(hash).HasValue() {
+		t.Collisions.Add(1)
 	} else {
-		t.Misses++
+		t.Misses.Add(1)
 	}
-	return Empty[CachedEvaluation]()
+	return Empty[CachedEvaluation](), Empty[Move]()
 }
 
-func (t *TranspositionTable) Put(hash uint64, depth int, score int, scoreType ScoreType) {
-	i := hash % uint64(t.Size)
-	t.Cache[i] = CachedEvaluation{
-		Depth:       depth,
-		Score:       score,
-		ScoreType:   scoreType,
-		ZobristHash: hash,
+func (t *TranspositionTable) Put(hash uint64, depth int, score int, scoreType ScoreType, bestMove Optional[Move]) {
+	generation := uint8(t.generation.Load())
+
+	existing := t.loadAny(hash)
+	if existing.HasValue() {
+		v := existing.Value()
+		if v.ZobristHash != hash && v.Generation == generation && v.Depth > depth {
+			// Prefer deeper entries from the current search
+			return
+		}
+		if bestMove.IsEmpty() && v.ZobristHash == hash {
+			// Keep the previous hash move around for move ordering
+			bestMove = v.BestMove
+		}
+	}
+
+	data := encodeEvaluation(CachedEvaluation{
+		Depth:      depth,
+		Score:      score,
+		ScoreType:  scoreType,
+		BestMove:   bestMove,
+		Generation: generation,
+	})
+
+	entry := &t.entries[hash%uint64(t.Size)]
+	atomic.StoreUint64(&entry.key, hash^data)
+	atomic.StoreUint64(&entry.data, data)
+}
+
+// Mate scores are relative to the root of the search. The table stores them
+// relative to the cached position instead so that they can be reused when the
+// same position is reached at a different ply.
+func scoreToTranspositionTable(score int, ply int) int {
+	if IsMate(score) && score > 0 {
+		return score + ply
+	} else if IsMate(score) {
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTranspositionTable(score int, ply int) int {
+	if IsMate(score) && score > 0 {
+		return score - ply
+	} else if IsMate(score) {
+		return score + ply
 	}
+	return score
 }
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranspositionTableMateScores(t *testing.T) {
+	// mate in 3 from the root, found at ply 2 => mate in 2 from the cached position
+	score := UnwrapReturn(MateInNScore(3))
+	stored := scoreToTranspositionTable(score, 2)
+	assert.Equal(t, UnwrapReturn(MateInNScore(2)), stored)
+
+	// reaching the same position at ply 4 => mate in 4 from the root
+	assert.Equal(t, UnwrapReturn(MateInNScore(4)), scoreFromTranspositionTable(stored, 4))
+
+	score = UnwrapReturn(MateInNScore(-3))
+	stored = scoreToTranspositionTable(score, 2)
+	assert.Equal(t, UnwrapReturn(MateInNScore(-2)), stored)
+	assert.Equal(t, UnwrapReturn(MateInNScore(-4)), scoreFromTranspositionTable(stored, 4))
+
+	assert.Equal(t, 150, scoreToTranspositionTable(150, 3))
+	assert.Equal(t, -150, scoreFromTranspositionTable(-150, 3))
+}
+
+func TestTranspositionTableReplacement(t *testing.T) {
+	table := NewTranspositionTable(1024)
+	size := uint64(table.Size)
+
+	move := MoveFromString("e2e4", QuietMove)
+
+	table.Put(1, 4, 100, Exact, Some(move))
+
+	cached, hashMove := table.Get(1, 4)
+	assert.True(t, cached.HasValue())
+	assert.Equal(t, 100, cached.Value().Score)
+	assert.Equal(t, Some(move), hashMove)
+
+	// too shallow to use the score, but the move is still useful for ordering
+	cached, hashMove = table.Get(1, 5)
+	assert.True(t, cached.IsEmpty())
+	assert.Equal(t, Some(move), hashMove)
+
+	// a shallower entry from the same search doesn't replace a deeper one
+	table.Put(1+size, 2, 50, Exact, Empty[Move]())
+	cached, _ = table.Get(1, 4)
+	assert.True(t, cached.HasValue())
+
+	// but entries from older searches are replaced
+	table.NewSearch()
+	table.Put(1+size, 2, 50, Exact, Empty[Move]())
+	cached, _ = table.Get(1, 4)
+	assert.True(t, cached.IsEmpty())
+	cached, _ = table.Get(1+size, 2)
+	assert.True(t, cached.HasValue())
+}
+
+func TestTranspositionTableSearchesFewerMoves(t *testing.T) {
+	fen := "r3k2r/1bq1bppp/pp2p3/2p1n3/P3PP2/2PBN3/1P1BQ1PP/R4RK1 b kq - 0 16"
+
+	withTable := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5), CreateEvaluator: Some(CreateBasicEvaluator)})
+	withoutTable := countSearchMoves(t, fen, SearchOptions{MaxDepth: Some(5), CreateEvaluator: Some(CreateBasicEvaluator), WithoutTranspositionTable: true})
+
+	fmt.Println("with table", withTable, "moves, without table", withoutTable, "moves")
+	assert.Less(t, withTable, withoutTable)
+}
+
+func TestTranspositionTableFindsMate(t *testing.T) {
+	fen := "5b2/3kp2p/4r3/1p6/4n3/p3P1p1/3p1r2/6K1 b - - 1 46"
+
+	result, score, err := Search(fen, SearchOptions{MaxDepth: Some(5), CreateEvaluator: Some(CreateBasicEvaluator)})
+	assert.True(t, IsNil(err), err)
+
+	assert.Equal(t, "mate+1", ScoreString(score))
+	assert.Equal(t, "d2d1q", result[0].String())
+}
+
+func countSearchMoves(t *testing.T, fen string, options SearchOptions) int {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	unregister, helper := NewSearchHelper(g, options)
+	defer unregister()
+
+	unregisterCounter, counter := NewMoveCounter(helper.GameState)
+	defer unregisterCounter()
+
+	_, err = helper.Search()
+	assert.True(t, IsNil(err), err)
+
+	return counter.NumMoves()
+}
@@ -16,6 +16,9 @@ type VariationMovePrioritizer struct {
 
 	historyVariationIndex []Optional[int]
 
+	// Reused by sortMoves
+	prioritized []Move
+
 	noCopy NoCopy
 }
 
@@ -60,7 +63,10 @@ func (gen *VariationMovePrioritizer) resetSortedVariations(sortedVariations [][]
 	gen.historyVariationIndex = []Optional[int]{}
 }
 
-func (gen *VariationMovePrioritizer) AfterMove(move Move) {
+func (gen *VariationMovePrioritizer) recordCutoff(move Move, currentDepth int, depthRemaining int) {
+}
+
+func (gen *VariationMovePrioritizer) AfterMove(move Move, update *BoardUpdate) {
 	previous := gen.currentVariationIndex
 
 	if gen.currentDepth == 0 {
@@ -89,7 +95,7 @@ func (gen *VariationMovePrioritizer) AfterMove(move Move) {
 	gen.historyVariationIndex = append(gen.historyVariationIndex, previous)
 }
 
-func (gen *VariationMovePrioritizer) AfterUndo() {
+func (gen *VariationMovePrioritizer) AfterUndo(update *BoardUpdate) {
 	gen.currentVariationIndex, gen.historyVariationIndex = PopValue(gen.historyVariationIndex, Empty[int]())
 	gen.currentDepth--
 }
@@ -112,28 +118,42 @@ func (gen *VariationMovePrioritizer) String() string {
 	return "VariationMovePrioritizer[empty]"
 }
 
-func (gen *VariationMovePrioritizer) sortMoves(moves *[]Move) Error {
-	moveScores := map[Move]int{}
-
+// principalMoves are the next moves of the principal variations, in order
+func (gen *VariationMovePrioritizer) principalMoves(output *[]Move) {
 	if gen.currentDepth == 0 {
-		for i, variation := range gen.sortedVariations {
-			moveScores[variation[0].Move] = i
+		for _, variation := range gen.sortedVariations {
+			*output = append(*output, variation[0].Move)
 		}
 	} else if gen.currentVariationIndex.HasValue() {
 		i := gen.currentVariationIndex.Value()
 		j := gen.currentDepth
 		variation := gen.sortedVariations[i]
 		if j < len(variation) {
-			moveScores[variation[j].Move] = i
+			*output = append(*output, variation[j].Move)
 		}
 	}
+}
 
-	SortMinFirst(moves, func(move Move) int {
-		if score, contains := moveScores[move]; contains {
-			return score
-		} else {
-			return Inf
+func (gen *VariationMovePrioritizer) killerMoves(currentDepth int, output *[]Move) {
+}
+
+// sortMoves moves the principal variations to the front, in order. The sort
+// is stable so the order doesn't depend on anything but the input.
+func (gen *VariationMovePrioritizer) sortMoves(currentDepth int, moves *[]Move) Error {
+	gen.prioritized = gen.prioritized[:0]
+	gen.principalMoves(&gen.prioritized)
+
+	if len(gen.prioritized) == 0 {
+		return NilError
+	}
+
+	sortMovesMaxFirst(moves, func(move Move) int {
+		for i, m := range gen.prioritized {
+			if m == move {
+				return -i
+			}
 		}
+		return -Inf
 	})
 
 	return NilError
@@ -0,0 +1,401 @@
+package tablebase
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/cricklet/chessgo/internal/bitboards"
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
+)
+
+// Generate builds the table for `material` and every table that a capture or
+// promotion can lead to. Tables that are already loaded are reused.
+func (tbs *Tablebases) Generate(material Material) Error {
+	if material.isDrawn() || tbs.Table(material).HasValue() {
+		return NilError
+	}
+
+	for _, sub := range material.subMaterials() {
+		err := tbs.Generate(sub)
+		if !IsNil(err) {
+			return err
+		}
+	}
+
+	start := time.Now()
+	table, err := tbs.generateTable(material)
+	if !IsNil(err) {
+		return Errorf("couldn't generate %v: %w", material, err)
+	}
+	tbs.tables[material] = table
+
+	tbs.Logger.Println("generated", material, "in", time.Since(start).Round(time.Millisecond))
+	return NilError
+}
+
+// generator solves a table by retrograde analysis. Checkmates are solved at
+// distance 0. Then at each distance n, positions with a move to a loss in n-1
+// plies become wins in n plies, and positions where every move leads to a win
+// in at most n-1 plies become losses in n plies. Only the predecessors of the
+// positions solved at n-1 need to be checked. Once nothing is left to check,
+// the remaining positions are draws.
+type generator struct {
+	tbs   *Tablebases
+	table *Table
+
+	pending []bool
+
+	// Positions that are known to be solved at a later distance, eg because a
+	// capture wins in more plies
+	scheduled [maxDistanceToMate + 1][]int
+	// The distance at which a position was last checked, plus one
+	checked []uint8
+}
+
+// solution is the outcome of checking a position at some distance
+type solution struct {
+	index int
+
+	solved bool
+	value  byte
+
+	// Set when the position will be solved at this distance
+	later Optional[int]
+}
+
+func (tbs *Tablebases) generateTable(material Material) (*Table, Error) {
+	table := newTable(material)
+	gen := generator{
+		tbs:     tbs,
+		table:   table,
+		pending: make([]bool, len(table.values)),
+		checked: make([]uint8, len(table.values)),
+	}
+
+	candidates := make([]int, len(table.values))
+	for i := range candidates {
+		gen.pending[i] = true
+		candidates[i] = i
+	}
+
+	for n := 0; ; n++ {
+		solutions, err := gen.check(n, candidates)
+		if !IsNil(err) {
+			return nil, err
+		}
+
+		solved := []int{}
+		for _, s := range solutions {
+			if s.solved {
+				table.values[s.index] = s.value
+				gen.pending[s.index] = false
+				if s.value != 0 {
+					solved = append(solved, s.index)
+				}
+			} else if s.later.HasValue() {
+				if s.later.Value() > maxDistanceToMate {
+					return nil, Errorf("mate is further than %v plies", maxDistanceToMate)
+				}
+				gen.scheduled[s.later.Value()] = append(gen.scheduled[s.later.Value()], s.index)
+			}
+		}
+
+		if n == maxDistanceToMate {
+			break
+		}
+
+		candidates = gen.candidates(n+1, solved)
+		if len(candidates) == 0 && gen.nothingScheduledAfter(n+1) {
+			break
+		}
+	}
+
+	// Everything that's left is a draw, and draws are already zero
+	return table, NilError
+}
+
+func (gen *generator) nothingScheduledAfter(n int) bool {
+	for i := n; i < len(gen.scheduled); i++ {
+		if len(gen.scheduled[i]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// candidates returns the pending positions that might be solved at distance
+// n, each only once
+func (gen *generator) candidates(n int, solved []int) []int {
+	result := []int{}
+	add := func(index int) {
+		if gen.pending[index] && gen.checked[index] != uint8(n+1) {
+			gen.checked[index] = uint8(n + 1)
+			result = append(result, index)
+		}
+	}
+
+	for _, index := range gen.scheduled[n] {
+		add(index)
+	}
+	gen.scheduled[n] = nil
+
+	squares := make([]int, len(gen.table.indexing.pieces))
+	for _, index := range solved {
+		gen.eachPredecessor(index, squares, add)
+	}
+
+	return result
+}
+
+// eachPredecessor calls `f` with every position that can reach `index` with a
+// quiet move. Captures and promotions come from other tables.
+func (gen *generator) eachPredecessor(index int, squares []int, f func(int)) {
+	x := &gen.table.indexing
+	player := x.squares(index, squares)
+	mover := player.Other()
+
+	occupied := Bitboard(0)
+	for _, square := range squares {
+		occupied |= SingleBitboard(square)
+	}
+
+	for i, piece := range x.pieces {
+		if piece.Player() != mover {
+			continue
+		}
+
+		start := squares[i]
+		var origins Bitboard
+		switch piece.PieceType() {
+		case King:
+			origins = KingAttackMasks[start]
+		case Knight:
+			origins = KnightAttackMasks[start]
+		case Bishop:
+			origins = BishopMagicTable.Attacks(start, occupied)
+		case Rook:
+			origins = RookMagicTable.Attacks(start, occupied)
+		case Queen:
+			origins = BishopMagicTable.Attacks(start, occupied) | RookMagicTable.Attacks(start, occupied)
+		case Pawn:
+			origins = pawnOrigins(mover, start, occupied)
+		}
+		origins &= ^occupied
+
+		origins.EachIndexOfOneCallback(func(origin int) {
+			squares[i] = origin
+			f(x.index(mover, squares))
+		})
+		squares[i] = start
+	}
+}
+
+// pawnOrigins are the squares a pawn could have been pushed from
+func pawnOrigins(player Player, square int, occupied Bitboard) Bitboard {
+	back := -8
+	startRank := 1
+	if player == Black {
+		back = 8
+		startRank = 6
+	}
+
+	origin := square + back
+	if origin/8 == 0 || origin/8 == 7 {
+		return 0
+	}
+
+	result := SingleBitboard(origin)
+	if double := origin + back; double/8 == startRank && occupied&result == 0 {
+		result |= SingleBitboard(double)
+	}
+	return result
+}
+
+// check solves the candidates at distance n
+func (gen *generator) check(n int, candidates []int) ([]solution, Error) {
+	const chunkSize = 1024
+
+	numWorkers := runtime.NumCPU()
+	next := atomic.Int64{}
+	solutions := make([][]solution, numWorkers)
+	errs := make([]Error, numWorkers)
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			squares := make([]int, len(gen.table.indexing.pieces))
+			moves := make([]Move, 0, 64)
+
+			for {
+				start := int(next.Add(chunkSize)) - chunkSize
+				if start >= len(candidates) {
+					return
+				}
+
+				for _, index := range candidates[start:MinInt(start+chunkSize, len(candidates))] {
+					s, err := gen.solve(index, n, squares, &moves)
+					if !IsNil(err) {
+						errs[w] = err
+						return
+					}
+					if s.solved || s.later.HasValue() {
+						solutions[w] = append(solutions[w], s)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	result := []solution{}
+	for _, s := range solutions {
+		result = append(result, s...)
+	}
+	return result, Join(errs...)
+}
+
+// gameForIndex sets up the position, or returns nil if it's not a legal
+// position with the player to move or if another index is used for it
+func (gen *generator) gameForIndex(index int, squares []int) *GameState {
+	x := &gen.table.indexing
+	player := x.squares(index, squares)
+	if x.index(player, squares) != index {
+		return nil
+	}
+
+	board := BoardArray{}
+	for i, square := range squares {
+		if board[square] != XX {
+			return nil
+		}
+
+		piece := x.pieces[i]
+		rank := square / 8
+		if piece.PieceType() == Pawn && (rank == 0 || rank == 7) {
+			return nil
+		}
+		board[square] = piece
+	}
+
+	g := NewGameState(board, player, [2][2]bool{}, Empty[FileRank](), 0, 1)
+	if search.KingIsInCheck(g.Bitboards, player.Other()) {
+		return nil
+	}
+	return g
+}
+
+// childResult probes the position after a move. Captures and promotions lead
+// to another table. Otherwise, the result is only known once the position
+// isn't pending.
+//
+// En-passant isn't possible in positions from the table, so it's ignored
+// after double pawn pushes.
+func (gen *generator) childResult(g *GameState, move Move) (Optional[search.TablebaseResult], Error) {
+	if move.MoveType.Captures() || move.PromotionPiece.HasValue() {
+		result := gen.tbs.Probe(g)
+		if result.IsEmpty() {
+			material, _ := materialForGame(g)
+			return result, Errorf("missing table %v", material)
+		}
+		return result, NilError
+	}
+
+	index := gen.table.indexForGame(g, false)
+	if gen.pending[index] {
+		return Empty[search.TablebaseResult](), NilError
+	}
+	return Some(decodeResult(gen.table.values[index])), NilError
+}
+
+func (gen *generator) solve(index int, n int, squares []int, moves *[]Move) (solution, Error) {
+	unsolved := solution{index: index}
+	solvedAs := func(result search.TablebaseResult) solution {
+		return solution{index: index, solved: true, value: encodeResult(result)}
+	}
+
+	g := gen.gameForIndex(index, squares)
+	if g == nil {
+		return solvedAs(search.TablebaseResult{Outcome: search.Draw}), NilError
+	}
+
+	*moves = (*moves)[:0]
+	err := search.GenerateLegalMoves(g, moves)
+	if !IsNil(err) {
+		return unsolved, err
+	}
+
+	if len(*moves) == 0 {
+		if search.PlayerIsInCheck(g) {
+			return solvedAs(search.TablebaseResult{Outcome: search.Loss}), NilError
+		}
+		return solvedAs(search.TablebaseResult{Outcome: search.Draw}), NilError
+	}
+
+	win := Empty[int]()
+	loss := Empty[int]()
+	draw := false
+	unknown := false
+
+	for _, move := range *moves {
+		var update BoardUpdate
+		err := g.PerformMove(move, &update)
+		if !IsNil(err) {
+			return unsolved, err
+		}
+
+		child, err := gen.childResult(g, move)
+
+		undoErr := g.UndoUpdate(&update)
+		if !IsNil(err) || !IsNil(undoErr) {
+			return unsolved, Join(err, undoErr)
+		}
+
+		if child.IsEmpty() {
+			unknown = true
+			continue
+		}
+
+		result := child.Value().FromEnemy()
+		switch result.Outcome {
+		case search.Win:
+			if win.IsEmpty() || result.DistanceToMate < win.Value() {
+				win = Some(result.DistanceToMate)
+			}
+		case search.Loss:
+			if loss.IsEmpty() || result.DistanceToMate > loss.Value() {
+				loss = Some(result.DistanceToMate)
+			}
+		case search.Draw:
+			draw = true
+		}
+	}
+
+	if win.HasValue() {
+		if win.Value() > n {
+			unsolved.later = win
+			return unsolved, NilError
+		}
+		return solvedAs(search.TablebaseResult{Outcome: search.Win, DistanceToMate: win.Value()}), NilError
+	}
+
+	if unknown {
+		return unsolved, NilError
+	}
+
+	if draw {
+		return solvedAs(search.TablebaseResult{Outcome: search.Draw}), NilError
+	}
+
+	if loss.Value() > n {
+		unsolved.later = loss
+		return unsolved, NilError
+	}
+	return solvedAs(search.TablebaseResult{Outcome: search.Loss, DistanceToMate: loss.Value()}), NilError
+}
@@ -0,0 +1,157 @@
+package tablebase
+
+import (
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// Positions are indexed by the player to move, then the white king and then
+// every other piece. The white king only needs to be indexed on some squares
+// because the board can be flipped and rotated without changing the result.
+// Without pawns, it's restricted to the a1-d1-d4 triangle. With pawns, the
+// board can only be flipped between the king and queen side.
+//
+// Some indices aren't used, eg when identical pieces are out of order, see
+// indexing.index.
+
+var _triangleSlots, _triangleSquares = kingSlots(func(file int, rank int) bool {
+	return file <= 3 && rank <= file
+})
+
+var _halfBoardSlots, _halfBoardSquares = kingSlots(func(file int, rank int) bool {
+	return file <= 3
+})
+
+func kingSlots(inSlot func(file int, rank int) bool) ([64]int, []int) {
+	slots := [64]int{}
+	squares := []int{}
+	for square := 0; square < 64; square++ {
+		slots[square] = -1
+		if inSlot(square%8, square/8) {
+			slots[square] = len(squares)
+			squares = append(squares, square)
+		}
+	}
+	return slots, squares
+}
+
+// symmetry maps squares so that the white king ends up in a king slot
+type symmetry struct {
+	flipFile  bool
+	flipRank  bool
+	transpose bool
+}
+
+func symmetryForKing(square int, pawns bool) symmetry {
+	s := symmetry{}
+	s.flipFile = square%8 > 3
+	if pawns {
+		return s
+	}
+
+	s.flipRank = square/8 > 3
+	square = s.apply(square)
+	s.transpose = square/8 > square%8
+	return s
+}
+
+func (s symmetry) apply(square int) int {
+	if s.flipFile {
+		square ^= 7
+	}
+	if s.flipRank {
+		square ^= 56
+	}
+	if s.transpose {
+		square = square/8 | (square%8)*8
+	}
+	return square
+}
+
+// indexing describes the index space for a material
+type indexing struct {
+	pieces []Piece
+	pawns  bool
+
+	kingSlots   *[64]int
+	kingSquares []int
+
+	// The number of positions for one player to move
+	perPlayer int
+}
+
+func newIndexing(material Material) indexing {
+	result := indexing{
+		pieces:      material.pieces(),
+		pawns:       material.hasPawns(),
+		kingSlots:   &_triangleSlots,
+		kingSquares: _triangleSquares,
+	}
+	if result.pawns {
+		result.kingSlots = &_halfBoardSlots
+		result.kingSquares = _halfBoardSquares
+	}
+
+	result.perPlayer = len(result.kingSquares)
+	for i := 1; i < len(result.pieces); i++ {
+		result.perPlayer *= 64
+	}
+	return result
+}
+
+func (x indexing) size() int {
+	return 2 * x.perPlayer
+}
+
+// index returns the index for the squares of x.pieces. Every symmetric copy of
+// a position has the same index.
+func (x indexing) index(player Player, squares []int) int {
+	s := symmetryForKing(squares[0], x.pawns)
+	result := x.indexWithSymmetry(player, squares, s)
+
+	// A king on the a1-h8 diagonal stays in the triangle when the board is
+	// flipped along the diagonal
+	king := s.apply(squares[0])
+	if !x.pawns && king/8 == king%8 {
+		s.transpose = !s.transpose
+		result = MinInt(result, x.indexWithSymmetry(player, squares, s))
+	}
+
+	return result
+}
+
+func (x indexing) indexWithSymmetry(player Player, squares []int, s symmetry) int {
+	buffer := [MaxPieces]int{}
+	transformed := buffer[:len(squares)]
+	for i, square := range squares {
+		transformed[i] = s.apply(square)
+	}
+
+	// Identical pieces are indexed in increasing order of their squares
+	for i := 2; i < len(transformed); i++ {
+		for j := i + 1; j < len(transformed); j++ {
+			if x.pieces[i] == x.pieces[j] && transformed[i] > transformed[j] {
+				transformed[i], transformed[j] = transformed[j], transformed[i]
+			}
+		}
+	}
+
+	index := x.kingSlots[transformed[0]]
+	for i := 1; i < len(transformed); i++ {
+		index = index*64 + transformed[i]
+	}
+	return int(player)*x.perPlayer + index
+}
+
+// squares is the inverse of index
+func (x indexing) squares(index int, squares []int) Player {
+	player := Player(index / x.perPlayer)
+	index = index % x.perPlayer
+
+	for i := len(x.pieces) - 1; i > 0; i-- {
+		squares[i] = index % 64
+		index /= 64
+	}
+	squares[0] = x.kingSquares[index]
+
+	return player
+}
@@ -0,0 +1,155 @@
+package tablebase
+
+import (
+	"strings"
+
+	. "github.com/cricklet/chessgo/internal/bitboards"
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+)
+
+// MaxPieces is the most pieces, including kings, that a table can hold
+const MaxPieces = 4
+
+// Material names the pieces in an endgame, eg "KRKP" is a king and rook
+// against a king and pawn. The stronger side is always white, see
+// canonicalMaterial.
+type Material string
+
+var _materialOrder = []PieceType{Queen, Rook, Bishop, Knight, Pawn}
+var _materialValues = [6]int{Rook: 5, Knight: 3, Bishop: 3, Queen: 9, Pawn: 1}
+
+func ParseMaterial(s string) (Material, Error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, "K") || strings.Count(s, "K") != 2 {
+		return "", Errorf("material '%v' needs two kings, eg KRK", s)
+	}
+	if len(s) > MaxPieces {
+		return "", Errorf("material '%v' has more than %v pieces", s, MaxPieces)
+	}
+
+	pieces := [2][]PieceType{}
+	player := White
+	for i, c := range s {
+		if c == 'K' {
+			if i > 0 {
+				player = Black
+			}
+			continue
+		}
+		piece, err := PieceFromRune(c)
+		if !IsNil(err) || piece.PieceType() == King {
+			return "", Errorf("invalid piece '%c' in material '%v'", c, s)
+		}
+		pieces[player] = append(pieces[player], piece.PieceType())
+	}
+
+	return canonicalMaterial(pieces), NilError
+}
+
+// materialForGame returns the material on the board and whether the board
+// has to be mirrored to match it
+func materialForGame(g *GameState) (Material, bool) {
+	pieces := [2][]PieceType{}
+	for player := White; player <= Black; player++ {
+		for _, pieceType := range _materialOrder {
+			for i := 0; i < OnesCount(g.Bitboards.Players[player].Pieces[pieceType]); i++ {
+				pieces[player] = append(pieces[player], pieceType)
+			}
+		}
+	}
+
+	material := canonicalMaterial(pieces)
+	return material, material != materialName(pieces)
+}
+
+// canonicalMaterial puts the side with more valuable pieces first
+func canonicalMaterial(pieces [2][]PieceType) Material {
+	name := materialName(pieces)
+	mirrored := materialName([2][]PieceType{pieces[Black], pieces[White]})
+
+	value := func(ps []PieceType) int {
+		return ReduceSlice(ps, 0, func(sum int, p PieceType) int { return sum + _materialValues[p] })
+	}
+
+	whiteValue, blackValue := value(pieces[White]), value(pieces[Black])
+	if blackValue > whiteValue || blackValue == whiteValue && mirrored < name {
+		return mirrored
+	}
+	return name
+}
+
+func materialName(pieces [2][]PieceType) Material {
+	name := ""
+	for player := White; player <= Black; player++ {
+		name += "K"
+		for _, pieceType := range _materialOrder {
+			for _, p := range pieces[player] {
+				if p == pieceType {
+					name += strings.ToUpper(pieceType.String())
+				}
+			}
+		}
+	}
+	return Material(name)
+}
+
+// pieces lists the pieces in the order they're indexed, kings first
+func (m Material) pieces() []Piece {
+	result := []Piece{WK, BK}
+	player := White
+	for i, c := range m {
+		if c == 'K' {
+			if i > 0 {
+				player = Black
+			}
+			continue
+		}
+		piece, _ := PieceFromRune(c)
+		result = append(result, PieceForPlayer[player][piece.PieceType()])
+	}
+	return result
+}
+
+func (m Material) hasPawns() bool {
+	return strings.Contains(string(m), "P")
+}
+
+// isDrawn is true if neither side can possibly mate, eg KBK
+func (m Material) isDrawn() bool {
+	return m == "KK" || m == "KBK" || m == "KNK"
+}
+
+// subMaterials are the endgames that a capture or promotion leads to
+func (m Material) subMaterials() []Material {
+	pieces := m.pieces()
+	result := []Material{}
+
+	add := func(ps []Piece) {
+		split := [2][]PieceType{}
+		for _, p := range ps {
+			if p.PieceType() != King {
+				split[p.Player()] = append(split[p.Player()], p.PieceType())
+			}
+		}
+		material := canonicalMaterial(split)
+		if !material.isDrawn() && !Contains(result, material) {
+			result = append(result, material)
+		}
+	}
+
+	for i := 2; i < len(pieces); i++ {
+		captured := append(append([]Piece{}, pieces[:i]...), pieces[i+1:]...)
+		add(captured)
+
+		if pieces[i].PieceType() == Pawn {
+			for _, promotion := range []PieceType{Queen, Rook, Bishop, Knight} {
+				promoted := append([]Piece{}, pieces...)
+				promoted[i] = PieceForPlayer[pieces[i].Player()][promotion]
+				add(promoted)
+			}
+		}
+	}
+
+	return result
+}
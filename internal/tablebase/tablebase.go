@@ -0,0 +1,266 @@
+package tablebase
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	. "github.com/cricklet/chessgo/internal/bitboards"
+	. "github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
+)
+
+// Table holds the result of every position for one material. Each position
+// is stored in a byte: 0 is a draw, otherwise it's one more than the plies to
+// mate. Odd distances are wins for the player to move and even distances are
+// losses.
+type Table struct {
+	Material Material
+
+	indexing indexing
+	values   []byte
+}
+
+func newTable(material Material) *Table {
+	indexing := newIndexing(material)
+	return &Table{
+		Material: material,
+		indexing: indexing,
+		values:   make([]byte, indexing.size()),
+	}
+}
+
+// maxDistanceToMate is the longest mate that fits in a byte
+const maxDistanceToMate = 253
+
+func encodeResult(result search.TablebaseResult) byte {
+	if result.Outcome == search.Draw {
+		return 0
+	}
+	return byte(result.DistanceToMate + 1)
+}
+
+func decodeResult(value byte) search.TablebaseResult {
+	if value == 0 {
+		return search.TablebaseResult{Outcome: search.Draw}
+	}
+
+	distance := int(value) - 1
+	if distance%2 == 1 {
+		return search.TablebaseResult{Outcome: search.Win, DistanceToMate: distance}
+	}
+	return search.TablebaseResult{Outcome: search.Loss, DistanceToMate: distance}
+}
+
+// Tablebases is a set of tables that can be probed by the search, see
+// search.SearchOptions.Tablebase
+type Tablebases struct {
+	Logger Logger
+
+	tables map[Material]*Table
+}
+
+var _ search.Tablebase = (*Tablebases)(nil)
+
+func NewTablebases() *Tablebases {
+	return &Tablebases{
+		Logger: &SilentLogger,
+		tables: map[Material]*Table{},
+	}
+}
+
+// Materials lists the tables that are loaded
+func (tbs *Tablebases) Materials() []Material {
+	result := []Material{}
+	for material := range tbs.tables {
+		result = append(result, material)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+func (tbs *Tablebases) Table(material Material) Optional[*Table] {
+	if table, ok := tbs.tables[material]; ok {
+		return Some(table)
+	}
+	return Empty[*Table]()
+}
+
+// Probe returns the result for the player to move if the position is covered
+// by one of the tables. Castling rights and en-passant aren't stored, so
+// positions with either aren't covered.
+func (tbs *Tablebases) Probe(g *GameState) Optional[search.TablebaseResult] {
+	if OnesCount(g.Bitboards.Occupied) > MaxPieces || g.EnPassantTarget.HasValue() {
+		return Empty[search.TablebaseResult]()
+	}
+	if g.PlayerAndCastlingSideAllowed != [2][2]bool{} {
+		return Empty[search.TablebaseResult]()
+	}
+
+	material, mirror := materialForGame(g)
+	if material.isDrawn() {
+		return Some(search.TablebaseResult{Outcome: search.Draw})
+	}
+
+	table, ok := tbs.tables[material]
+	if !ok {
+		return Empty[search.TablebaseResult]()
+	}
+
+	return Some(decodeResult(table.values[table.indexForGame(g, mirror)]))
+}
+
+// indexForGame finds the index of the position. When mirrored, the colors are
+// swapped and the board is flipped so that the white pieces become black.
+func (t *Table) indexForGame(g *GameState, mirror bool) int {
+	buffer := [MaxPieces]int{}
+	squares := buffer[:len(t.indexing.pieces)]
+	found := [MaxPieces]bool{}
+
+	player := g.Player
+	if mirror {
+		player = player.Other()
+	}
+
+	g.Bitboards.Occupied.EachIndexOfOneCallback(func(square int) {
+		piece := g.Board[square]
+		if mirror {
+			piece = PieceForPlayer[piece.Player().Other()][piece.PieceType()]
+			square ^= 56
+		}
+
+		for i, p := range t.indexing.pieces {
+			if p == piece && !found[i] {
+				found[i] = true
+				squares[i] = square
+				break
+			}
+		}
+	})
+
+	return t.indexing.index(player, squares)
+}
+
+const _tableMagic = "CGTB"
+const _tableVersion = 1
+const _tableExtension = ".tb"
+
+// Write writes the table gzipped, see ReadTable
+func (t *Table) Write(w io.Writer) Error {
+	zipped := gzip.NewWriter(w)
+
+	header := append([]byte(_tableMagic), _tableVersion, byte(len(t.Material)))
+	header = append(header, []byte(t.Material)...)
+
+	_, err := zipped.Write(header)
+	if err != nil {
+		return Wrap(err)
+	}
+	_, err = zipped.Write(t.values)
+	if err != nil {
+		return Wrap(err)
+	}
+
+	return Wrap(zipped.Close())
+}
+
+func ReadTable(r io.Reader) (*Table, Error) {
+	zipped, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, Wrap(err)
+	}
+	defer zipped.Close()
+
+	reader := bufio.NewReader(zipped)
+
+	header := make([]byte, len(_tableMagic)+2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, Wrap(err)
+	}
+	if string(header[:len(_tableMagic)]) != _tableMagic || header[len(_tableMagic)] != _tableVersion {
+		return nil, Errorf("not a version %v tablebase", _tableVersion)
+	}
+
+	name := make([]byte, header[len(header)-1])
+	if _, err := io.ReadFull(reader, name); err != nil {
+		return nil, Wrap(err)
+	}
+
+	material, parseErr := ParseMaterial(string(name))
+	if !IsNil(parseErr) {
+		return nil, parseErr
+	}
+	if material != Material(name) {
+		return nil, Errorf("material %v isn't canonical, expected %v", string(name), material)
+	}
+
+	table := newTable(material)
+	if _, err := io.ReadFull(reader, table.values); err != nil {
+		return nil, Errorf("table %v is truncated: %w", material, err)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		return nil, Errorf("table %v is too long", material)
+	}
+
+	return table, NilError
+}
+
+// Save writes each table to `dir`, eg data/tablebases/KRK.tb
+func (tbs *Tablebases) Save(dir string) Error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return Wrap(err)
+	}
+
+	for _, material := range tbs.Materials() {
+		file, err := os.Create(filepath.Join(dir, string(material)+_tableExtension))
+		if err != nil {
+			return Wrap(err)
+		}
+
+		writeErr := tbs.tables[material].Write(file)
+		closeErr := Wrap(file.Close())
+		if !IsNil(writeErr) || !IsNil(closeErr) {
+			return Join(writeErr, closeErr)
+		}
+	}
+
+	return NilError
+}
+
+// LoadTablebases reads every table in `dir`
+func LoadTablebases(dir string) (*Tablebases, Error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+_tableExtension))
+	if err != nil {
+		return nil, Wrap(err)
+	}
+
+	tbs := NewTablebases()
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, Wrap(err)
+		}
+
+		table, readErr := ReadTable(file)
+		file.Close()
+		if !IsNil(readErr) {
+			return nil, Errorf("couldn't read %v: %w", path, readErr)
+		}
+
+		tbs.tables[table.Material] = table
+	}
+
+	return tbs, NilError
+}
+
+// DefaultTablebasesDir is where cmd/tablebase writes the tables
+func DefaultTablebasesDir() string {
+	return filepath.Join(RootDir(), "data", "tablebases")
+}
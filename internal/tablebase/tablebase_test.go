@@ -0,0 +1,215 @@
+package tablebase
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cricklet/chessgo/internal/game"
+	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func probe(t *testing.T, tbs *Tablebases, fen string) search.TablebaseResult {
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+
+	result := tbs.Probe(g)
+	assert.True(t, result.HasValue(), fen)
+	return result.Value()
+}
+
+func TestParseMaterial(t *testing.T) {
+	for input, expected := range map[string]Material{
+		"KRK":  "KRK",
+		"kkr":  "KRK",
+		"KPKR": "KRKP",
+		"KNKB": "KBKN",
+	} {
+		material, err := ParseMaterial(input)
+		assert.True(t, IsNil(err), err)
+		assert.Equal(t, expected, material)
+	}
+
+	for _, input := range []string{"KRR", "RKK", "KRKRR", "KXK"} {
+		_, err := ParseMaterial(input)
+		assert.False(t, IsNil(err), input)
+	}
+
+	assert.ElementsMatch(t, []Material{"KRK", "KPK", "KQKR", "KRKR", "KRKB", "KRKN"}, Material("KRKP").subMaterials())
+}
+
+func TestSymmetricPositionsShareAnIndex(t *testing.T) {
+	for _, material := range []Material{"KRK", "KPK", "KRKN", "KNNK"} {
+		x := newIndexing(material)
+		squares := make([]int, len(x.pieces))
+		transformed := make([]int, len(x.pieces))
+
+		for i := 0; i < 1000; i++ {
+			for j := range squares {
+				squares[j] = RandomInt(8, 55)
+			}
+			index := x.index(Black, squares)
+
+			player := x.squares(index, transformed)
+			assert.Equal(t, Black, player)
+			assert.Equal(t, index, x.index(player, transformed))
+
+			for _, s := range []symmetry{{flipFile: true}, {flipRank: true}, {transpose: true}} {
+				if x.pawns && (s.flipRank || s.transpose) {
+					continue
+				}
+				for j, square := range squares {
+					transformed[j] = s.apply(square)
+				}
+				assert.Equal(t, index, x.index(Black, transformed), material)
+			}
+		}
+	}
+}
+
+func TestKRK(t *testing.T) {
+	tbs := NewTablebases()
+	assert.True(t, IsNil(tbs.Generate("KRK")))
+
+	// Mated
+	assert.Equal(t, search.TablebaseResult{Outcome: search.Loss}, probe(t, tbs, "k7/2K5/8/8/8/8/8/R7 b - - 0 1"))
+	// Mate in one
+	assert.Equal(t, search.TablebaseResult{Outcome: search.Win, DistanceToMate: 1}, probe(t, tbs, "k7/8/1K6/8/8/8/8/7R w - - 0 1"))
+	// The rook can be taken
+	assert.Equal(t, search.TablebaseResult{Outcome: search.Draw}, probe(t, tbs, "8/8/8/8/8/8/1r6/K1k5 w - - 0 1"))
+
+	// The colors and the board can be flipped
+	assert.Equal(t, search.TablebaseResult{Outcome: search.Win, DistanceToMate: 1}, probe(t, tbs, "7r/8/8/8/8/1k6/8/K7 b - - 0 1"))
+	assert.Equal(t, search.TablebaseResult{Outcome: search.Win, DistanceToMate: 1}, probe(t, tbs, "7k/8/6K1/8/8/8/8/R7 w - - 0 1"))
+
+	// KRK is won in at most 16 moves
+	maxDistance := 0
+	for _, value := range tbs.tables["KRK"].values {
+		maxDistance = MaxInt(maxDistance, decodeResult(value).DistanceToMate)
+	}
+	assert.Equal(t, 2*16, maxDistance)
+}
+
+func TestKPK(t *testing.T) {
+	tbs := NewTablebases()
+	assert.True(t, IsNil(tbs.Generate("KPK")))
+	assert.Equal(t, []Material{"KPK", "KQK", "KRK"}, tbs.Materials())
+
+	// The king is on the sixth rank in front of its pawn
+	assert.Equal(t, search.Win, probe(t, tbs, "4k3/8/4K3/4P3/8/8/8/8 w - - 0 1").Outcome)
+	assert.Equal(t, search.Loss, probe(t, tbs, "4k3/8/4K3/4P3/8/8/8/8 b - - 0 1").Outcome)
+	// Whoever has the opposition decides the game
+	assert.Equal(t, search.Draw, probe(t, tbs, "8/4k3/8/4K3/4P3/8/8/8 w - - 0 1").Outcome)
+	assert.Equal(t, search.Loss, probe(t, tbs, "8/4k3/8/4K3/4P3/8/8/8 b - - 0 1").Outcome)
+	// Rook pawns can't be won once the king reaches the corner
+	assert.Equal(t, search.Draw, probe(t, tbs, "k7/8/8/8/8/8/P7/1K6 w - - 0 1").Outcome)
+	// The same for black pawns
+	assert.Equal(t, search.Draw, probe(t, tbs, "8/8/8/4p3/4k3/8/4K3/8 b - - 0 1").Outcome)
+	assert.Equal(t, search.Loss, probe(t, tbs, "8/8/8/4p3/4k3/8/4K3/8 w - - 0 1").Outcome)
+
+	// Insufficient material
+	assert.Equal(t, search.Draw, probe(t, tbs, "8/8/3k4/8/3K4/3B4/8/8 w - - 0 1").Outcome)
+
+	// Positions with more pieces aren't covered
+	g, err := game.GamestateFromFenString("8/8/3k4/8/3K4/3PP3/8/8 w - - 0 1")
+	assert.True(t, IsNil(err), err)
+	assert.True(t, tbs.Probe(g).IsEmpty())
+}
+
+func TestEveryPositionMatchesItsBestMove(t *testing.T) {
+	tbs := NewTablebases()
+	assert.True(t, IsNil(tbs.Generate("KPK")))
+
+	for _, material := range tbs.Materials() {
+		table := tbs.tables[material]
+		gen := generator{tbs: tbs, table: table, pending: make([]bool, len(table.values))}
+
+		squares := make([]int, len(table.indexing.pieces))
+		moves := []Move{}
+		for index, value := range table.values {
+			s, err := gen.solve(index, maxDistanceToMate, squares, &moves)
+			assert.True(t, IsNil(err), err)
+			if !s.solved || s.value != value {
+				t.Fatalf("%v index %v is %v, expected %v", material, index, decodeResult(value), decodeResult(s.value))
+			}
+		}
+	}
+}
+
+func TestWriteAndReadTable(t *testing.T) {
+	tbs := NewTablebases()
+	assert.True(t, IsNil(tbs.Generate("KQK")))
+
+	table := tbs.tables["KQK"]
+
+	buffer := bytes.Buffer{}
+	assert.True(t, IsNil(table.Write(&buffer)))
+	assert.Less(t, buffer.Len(), len(table.values))
+
+	read, err := ReadTable(&buffer)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, table.Material, read.Material)
+	assert.Equal(t, table.values, read.values)
+
+	dir := t.TempDir()
+	assert.True(t, IsNil(tbs.Save(dir)))
+
+	loaded, err := LoadTablebases(dir)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, []Material{"KQK"}, loaded.Materials())
+}
+
+func TestSearchWithTablebase(t *testing.T) {
+	tbs := NewTablebases()
+	assert.True(t, IsNil(tbs.Generate("KRK")))
+
+	fen := "8/8/8/4k3/8/8/8/K6R w - - 0 1"
+	expected := probe(t, tbs, fen)
+	assert.Equal(t, search.Win, expected.Outcome)
+
+	pv, score, err := search.Search(fen, search.SearchOptions{
+		MaxDepth:  Some(3),
+		Tablebase: Some[search.Tablebase](tbs),
+	})
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, expected.Score(0), score, ScoreString(score))
+
+	// The best move keeps the shortest mate
+	g, err := game.GamestateFromFenString(fen)
+	assert.True(t, IsNil(err), err)
+	g.PerformMove(pv[0], &BoardUpdate{})
+	assert.Equal(t, expected.DistanceToMate-1, tbs.Probe(g).Value().DistanceToMate)
+}
+
+func loadCheckedInTablebases(t *testing.T) *Tablebases {
+	tbs, err := LoadTablebases(DefaultTablebasesDir())
+	assert.True(t, IsNil(err), err)
+	return tbs
+}
+
+func TestCheckedInKBNK(t *testing.T) {
+	tbs := loadCheckedInTablebases(t)
+	assert.Contains(t, tbs.Materials(), Material("KBNK"))
+
+	assert.Equal(t, search.Win, probe(t, tbs, "8/8/8/4k3/8/8/8/KBN5 w - - 0 1").Outcome)
+	// The knight or the bishop can be taken
+	assert.Equal(t, search.Draw, probe(t, tbs, "8/8/8/8/8/8/3kN3/K1B5 b - - 0 1").Outcome)
+
+	// KBNK is won in at most 33 moves
+	maxDistance := 0
+	for _, value := range tbs.tables["KBNK"].values {
+		maxDistance = MaxInt(maxDistance, decodeResult(value).DistanceToMate)
+	}
+	assert.Equal(t, 2*33, maxDistance)
+}
+
+func TestCheckedInKRKP(t *testing.T) {
+	tbs := loadCheckedInTablebases(t)
+	assert.Contains(t, tbs.Materials(), Material("KRKP"))
+
+	// The rook takes the pawn
+	assert.Equal(t, search.Win, probe(t, tbs, "8/8/8/8/8/3k4/1p6/1R4K1 w - - 0 1").Outcome)
+	// The pawn promotes and the rook has to give itself up for the queen
+	assert.Equal(t, search.Draw, probe(t, tbs, "7K/8/8/8/8/8/kp6/7R b - - 0 1").Outcome)
+}
@@ -2,19 +2,28 @@ package uci
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cricklet/chessgo/internal/chessgo"
 	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
 )
 
 type uciRunner struct {
 	Runner chessgo.ChessGoRunner
+
+	// Set while a search runs in the background, see SearchDone
+	searching bool
+	// The search waits for a "ponderhit" before its time limits apply
+	pondering bool
+	// The search only ends on "stop"
+	infinite bool
 }
 
 func NewUciRunner(r chessgo.ChessGoRunner) uciRunner {
-	return uciRunner{r}
+	return uciRunner{Runner: r}
 }
 
 func parseFen(input string) (string, Error) {
@@ -44,14 +53,273 @@ func parsePosition(input string) (Position, Error) {
 	return Position{Fen: fen, Moves: parseMoves(input)}, err
 }
 
+// _goKeywords end the list of moves after "searchmoves"
+var _goKeywords = []string{
+	"searchmoves", "ponder", "wtime", "btime", "winc", "binc", "movestogo",
+	"depth", "nodes", "mate", "movetime", "infinite",
+}
+
+// parseGo reads the search limits from eg "go wtime 1000 btime 1000 winc 10".
+// Without any limits, the search runs for a second. "go infinite" searches
+// until "stop".
+func parseGo(input string) (SearchParams, Error) {
+	fields := strings.Fields(input)
+
+	params := SearchParams{}
+	clock := Clock{}
+	hasClock := false
+
+	for i := 1; i < len(fields); i++ {
+		key := fields[i]
+		if key == "ponder" {
+			params.Ponder = true
+			continue
+		}
+		if key == "infinite" {
+			params.Infinite = true
+			continue
+		}
+		if key == "searchmoves" {
+			for i+1 < len(fields) && !Contains(_goKeywords, fields[i+1]) {
+				params.SearchMoves = append(params.SearchMoves, fields[i+1])
+				i++
+			}
+			continue
+		}
+		if i+1 >= len(fields) {
+			return params, Errorf("missing value for '%v' in '%v'", key, input)
+		}
+
+		value, err := WrapReturn(strconv.Atoi(fields[i+1]))
+		if !IsNil(err) {
+			return params, Errorf("couldn't parse '%v' in '%v': %w", key, input, err)
+		}
+		i++
+
+		millis := time.Duration(value) * time.Millisecond
+
+		switch key {
+		case "wtime":
+			clock.Remaining[White] = millis
+			hasClock = true
+		case "btime":
+			clock.Remaining[Black] = millis
+			hasClock = true
+		case "winc":
+			clock.Increment[White] = millis
+		case "binc":
+			clock.Increment[Black] = millis
+		case "movestogo":
+			clock.MovesToGo = Some(value)
+		case "movetime":
+			params.Duration = Some(millis)
+		case "depth":
+			params.Depth = Some(value)
+		case "nodes":
+			params.Nodes = Some(value)
+		case "mate":
+			params.Mate = Some(value)
+		}
+	}
+
+	if hasClock {
+		params.Clock = Some(clock)
+	}
+	if !params.Infinite && params.Clock.IsEmpty() && params.Duration.IsEmpty() && params.Depth.IsEmpty() && params.Nodes.IsEmpty() && params.Mate.IsEmpty() {
+		params.Duration = Some(time.Second)
+	}
+
+	return params, NilError
+}
+
+// parseSetOption reads eg "setoption name BookFile value books/gm2001.bin".
+// Names can have spaces and aren't case sensitive.
+func parseSetOption(input string) (string, string, Error) {
+	s := strings.TrimPrefix(input, "setoption ")
+	if !strings.HasPrefix(s, "name ") {
+		return "", "", Errorf("couldn't parse option '%v'", input)
+	}
+	s = strings.TrimPrefix(s, "name ")
+
+	name, value, _ := strings.Cut(s, " value ")
+	return strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value), NilError
+}
+
+func (u *uciRunner) setOption(input string) Error {
+	name, value, err := parseSetOption(input)
+	if !IsNil(err) {
+		return err
+	}
+
+	switch name {
+	case "ownbook":
+		u.Runner.SetOwnBook(value == "true")
+	case "bookfile":
+		if value == "<empty>" {
+			value = ""
+		}
+		u.Runner.SetBookFile(value)
+	case "uci_limitstrength":
+		u.Runner.SetLimitStrength(value == "true")
+	case "uci_elo", "skill level":
+		n, err := WrapReturn(strconv.Atoi(value))
+		if !IsNil(err) {
+			return Errorf("couldn't parse '%v': %w", input, err)
+		}
+		if name == "uci_elo" {
+			u.Runner.SetElo(n)
+		} else {
+			u.Runner.SetSkillLevel(n)
+		}
+	}
+	return NilError
+}
+
+// SetInfoOutput sends an "info" line for each MultiPV line after every
+// completed depth of the later searches. `output` is called on the searching
+// goroutine.
+func (u *uciRunner) SetInfoOutput(output func(line string)) {
+	u.Runner.SetOnIteration(func(result search.SearchResult) {
+		for _, line := range infoLines(result) {
+			output(line)
+		}
+	})
+}
+
+// infoLines formats the progress of a search, eg
+//
+//	info depth 6 seldepth 11 multipv 1 score cp 35 nodes 48211 nps 960000 time 50 pv e2e4 e7e5
+func infoLines(result search.SearchResult) []string {
+	lines := []string{}
+	for i, line := range result.Lines {
+		info := fmt.Sprintf("info depth %v seldepth %v multipv %v score %v",
+			result.Depth, result.SelDepth, i+1, UciScoreString(line.First))
+		if i == 0 && result.Bound == search.BetaFailLowerBound {
+			info += " lowerbound"
+		} else if i == 0 && result.Bound == search.AlphaFailUpperBound {
+			info += " upperbound"
+		}
+		info += fmt.Sprintf(" nodes %v nps %v time %v", result.Nodes, result.NPS, result.Time.Milliseconds())
+		if len(line.Second) > 0 {
+			info += " pv " + strings.Join(MapSlice(line.Second, func(m Move) string { return m.String() }), " ")
+		}
+		lines = append(lines, info)
+	}
+	return lines
+}
+
+// bestMove formats the result of a search, eg
+//
+//	info depth 6 score mate 2 pv d1h5 g7g6 h5e5
+//	bestmove d1h5 ponder g7g6
+func (u *uciRunner) bestMove(move Optional[string], score Optional[int], depth int, err Error) ([]string, Error) {
+	if !IsNil(err) {
+		return nil, err
+	}
+
+	if move.IsEmpty() {
+		return []string{"bestmove forfeit"}, NilError
+	}
+
+	result := []string{}
+	if score.HasValue() {
+		info := fmt.Sprintf("info depth %v score %v", depth, UciScoreString(score.Value()))
+		if pv := u.Runner.PrincipalVariation(); len(pv) > 0 {
+			info += " pv " + strings.Join(pv, " ")
+		}
+		result = append(result, info)
+	}
+
+	ponderMove, err := u.Runner.PonderMove()
+	if !IsNil(err) {
+		return nil, err
+	}
+
+	if ponderMove.HasValue() {
+		return append(result, fmt.Sprintf("bestmove %v ponder %v", move.Value(), ponderMove.Value())), NilError
+	}
+	return append(result, fmt.Sprintf("bestmove %v", move.Value())), NilError
+}
+
+// SearchDone is closed when the running search is done, FinishSearch then
+// returns its best move. It's nil while there's no search, or while the search
+// waits for a "ponderhit" or "stop".
+func (u *uciRunner) SearchDone() <-chan struct{} {
+	if !u.searching || u.pondering || u.infinite {
+		return nil
+	}
+	return u.Runner.Done()
+}
+
+// FinishSearch waits for the running search and returns its best move
+func (u *uciRunner) FinishSearch() ([]string, Error) {
+	if !u.searching {
+		return nil, NilError
+	}
+
+	u.searching = false
+	u.pondering = false
+	u.infinite = false
+	move, score, depth, _, err := u.Runner.Wait()
+	return u.bestMove(move, score, depth, err)
+}
+
+// stopSearch is used when the opponent didn't play the expected move, the
+// result of the search is thrown away.
+func (u *uciRunner) stopSearch() Error {
+	if !u.searching {
+		return NilError
+	}
+
+	u.Runner.Stop()
+	_, err := u.FinishSearch()
+	return err
+}
+
+// HandleInput responds to one UCI command. Searches run in the background, so
+// "go" returns straight away. The best move is returned by "stop", or by
+// FinishSearch once SearchDone is closed.
 func (u *uciRunner) HandleInput(input string) ([]string, Error) {
 	result := []string{}
+
+	if input == "ponderhit" {
+		// The search continues with its time limits. "go ponder infinite"
+		// keeps searching until "stop".
+		u.Runner.PonderHit()
+		u.pondering = false
+		return result, NilError
+	} else if input == "stop" {
+		if u.searching {
+			u.Runner.Stop()
+		}
+		return u.FinishSearch()
+	} else if u.searching && input != "isready" {
+		// The GUI should have stopped the search before sending anything else
+		err := u.stopSearch()
+		if !IsNil(err) {
+			return result, err
+		}
+	}
+
 	if input == "uci" {
 		result = append(result, "id name chessgo 1")
 		result = append(result, "id author Kenrick Rilee")
+		result = append(result, "option name Ponder type check default false")
+		result = append(result, "option name OwnBook type check default false")
+		result = append(result, "option name BookFile type string default <empty>")
+		result = append(result, "option name UCI_LimitStrength type check default false")
+		result = append(result, fmt.Sprintf("option name UCI_Elo type spin default %v min %v max %v",
+			search.MaxSkillElo, search.MinSkillElo, search.MaxSkillElo))
+		result = append(result, fmt.Sprintf("option name Skill Level type spin default %v min 0 max %v",
+			search.MaxSkillLevel, search.MaxSkillLevel))
 		result = append(result, "uciok")
 	} else if input == "ucinewgame" {
 		u.Runner.Reset()
+	} else if strings.HasPrefix(input, "setoption ") {
+		err := u.setOption(input)
+		if !IsNil(err) {
+			return result, err
+		}
 	} else if input == "isready" {
 		result = append(result, "readyok")
 	} else if input == "fen" {
@@ -76,16 +344,19 @@ func (u *uciRunner) HandleInput(input string) ([]string, Error) {
 			}
 		}
 	} else if strings.HasPrefix(input, "go") {
-		move, _, _, err := u.Runner.Search(SearchParams{Duration: Some(time.Second)})
+		params, err := parseGo(input)
 		if !IsNil(err) {
 			return result, err
 		}
 
-		if move.IsEmpty() {
-			result = append(result, "bestmove forfeit")
-		} else {
-			result = append(result, fmt.Sprintf("bestmove %v", move.Value()))
+		err = u.Runner.SearchAsync(params)
+		if !IsNil(err) {
+			return result, err
 		}
+
+		u.searching = true
+		u.pondering = params.Ponder
+		u.infinite = params.Infinite
 	}
 	return result, NilError
 }
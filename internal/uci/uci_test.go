@@ -5,15 +5,30 @@ import (
 	"fmt"
 	"log"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/cricklet/chessgo/internal/book"
 	"github.com/cricklet/chessgo/internal/chessgo"
 	. "github.com/cricklet/chessgo/internal/helpers"
+	"github.com/cricklet/chessgo/internal/search"
 	"github.com/stretchr/testify/assert"
 )
 
+// handleGo starts a search and waits for its best move
+func handleGo(r *uciRunner, input string) ([]string, Error) {
+	result, err := r.HandleInput(input)
+	if !IsNil(err) {
+		return result, err
+	}
+
+	<-r.SearchDone()
+	bestMove, err := r.FinishSearch()
+	return append(result, bestMove...), err
+}
+
 func TestUci(t *testing.T) {
 	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
 	inputs := []string{
@@ -22,15 +37,16 @@ func TestUci(t *testing.T) {
 		"position fen rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
 		"go",
 	}
-	r := uciRunner{runner}
+	r := NewUciRunner(runner)
 	for _, line := range inputs {
 		log.Println(r.HandleInput(line))
 	}
+	log.Println(r.FinishSearch())
 }
 
 func TestUciIndexBug2(t *testing.T) {
 	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
-	r := uciRunner{runner}
+	r := NewUciRunner(runner)
 	for _, line := range []string{
 		"isready",
 		"uci",
@@ -41,13 +57,13 @@ func TestUciIndexBug2(t *testing.T) {
 		assert.True(t, IsNil(err))
 	}
 
-	_, err := r.HandleInput("go")
+	_, err := handleGo(&r, "go")
 	assert.True(t, IsNil(err))
 }
 
 func TestUciIndexBug3(t *testing.T) {
 	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
-	r := uciRunner{runner}
+	r := NewUciRunner(runner)
 	for _, line := range []string{
 		"isready",
 		"uci",
@@ -58,13 +74,13 @@ func TestUciIndexBug3(t *testing.T) {
 		assert.True(t, IsNil(err))
 	}
 
-	_, err := r.HandleInput("go")
+	_, err := handleGo(&r, "go")
 	assert.True(t, IsNil(err))
 }
 
 func TestUciCastlingBug1(t *testing.T) {
 	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
-	r := uciRunner{runner}
+	r := NewUciRunner(runner)
 	fen := "rn1qk2r/ppp3pp/3b1n2/3ppb2/8/2NPBNP1/PPP2PBP/R2QK2R b KQkq - 15 8"
 	moves := []string{
 		"e8g8",
@@ -158,3 +174,370 @@ func TestUciStockfishManually(t *testing.T) {
 	}
 
 }
+
+func TestParseGo(t *testing.T) {
+	params, err := parseGo("go")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some(time.Second), params.Duration)
+	assert.True(t, params.Clock.IsEmpty())
+
+	params, err = parseGo("go wtime 300000 btime 290000 winc 2000 binc 1000 movestogo 12")
+	assert.True(t, IsNil(err))
+	assert.True(t, params.Duration.IsEmpty())
+	assert.Equal(t, Clock{
+		Remaining: [2]time.Duration{300 * time.Second, 290 * time.Second},
+		Increment: [2]time.Duration{2 * time.Second, time.Second},
+		MovesToGo: Some(12),
+	}, params.Clock.Value())
+
+	params, err = parseGo("go movetime 500")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some(500*time.Millisecond), params.Duration)
+
+	params, err = parseGo("go depth 4")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some(4), params.Depth)
+	assert.True(t, params.Duration.IsEmpty())
+
+	params, err = parseGo("go searchmoves e2e4 d2d4 movetime 500")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, []string{"e2e4", "d2d4"}, params.SearchMoves)
+	assert.Equal(t, Some(500*time.Millisecond), params.Duration)
+
+	params, err = parseGo("go depth 3 searchmoves a7a8q")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, []string{"a7a8q"}, params.SearchMoves)
+	assert.Equal(t, Some(3), params.Depth)
+
+	params, err = parseGo("go nodes 5000")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some(5000), params.Nodes)
+	assert.True(t, params.Duration.IsEmpty())
+
+	params, err = parseGo("go infinite")
+	assert.True(t, IsNil(err))
+	assert.True(t, params.Infinite)
+	assert.True(t, params.Duration.IsEmpty())
+
+	params, err = parseGo("go mate 2")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, Some(2), params.Mate)
+	assert.True(t, params.Duration.IsEmpty())
+
+	_, err = parseGo("go wtime")
+	assert.False(t, IsNil(err))
+}
+
+func TestUciSearchMoves(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	_, err := r.HandleInput("position fen 6k1/1R6/8/8/8/8/R7/6K1 w - - 0 1")
+	assert.True(t, IsNil(err))
+
+	result, err := handleGo(&r, "go depth 3 searchmoves g1h1 g1f1")
+	assert.True(t, IsNil(err))
+	fields := strings.Fields(result[len(result)-1])
+	assert.Contains(t, []string{"g1h1", "g1f1"}, fields[1])
+
+	// The next search isn't restricted
+	result, err = handleGo(&r, "go depth 3")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, "bestmove a2a8", result[len(result)-1])
+}
+
+func TestUciClock(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	_, err := r.HandleInput("position startpos moves e2e4")
+	assert.True(t, IsNil(err))
+
+	start := time.Now()
+	result, err := handleGo(&r, "go wtime 3000 btime 3000")
+	assert.True(t, IsNil(err))
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestUciMateScore(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	_, err := r.HandleInput("position fen 6k1/1R6/8/8/8/8/R7/6K1 w - - 0 1")
+	assert.True(t, IsNil(err))
+
+	result, err := handleGo(&r, "go depth 3")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, []string{
+		"info depth 3 score mate 1 pv a2a8",
+		"bestmove a2a8",
+	}, result)
+}
+
+func TestUciMate(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	_, err := r.HandleInput("position fen 6k1/8/8/8/8/8/R7/1R4K1 w - - 0 1")
+	assert.True(t, IsNil(err))
+
+	result, err := handleGo(&r, "go mate 2")
+	assert.True(t, IsNil(err))
+	assert.True(t, strings.HasPrefix(result[0], "info depth 3 score mate 2 "), result)
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "), result)
+}
+
+func TestUciInfinite(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	_, err := r.HandleInput("position startpos")
+	assert.True(t, IsNil(err))
+
+	result, err := r.HandleInput("go infinite")
+	assert.True(t, IsNil(err))
+	assert.Empty(t, result)
+
+	// Longer than the search would run for without limits
+	time.Sleep(1500 * time.Millisecond)
+	assert.True(t, r.searching)
+
+	// A ponderhit doesn't end an infinite search either
+	result, err = r.HandleInput("ponderhit")
+	assert.True(t, IsNil(err))
+	assert.Empty(t, result)
+
+	start := time.Now()
+	result, err = r.HandleInput("stop")
+	assert.True(t, IsNil(err))
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "), result)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestUciPonder(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	position := "position startpos moves e2e4 e7e5"
+	_, err := r.HandleInput(position)
+	assert.True(t, IsNil(err))
+
+	result, err := handleGo(&r, "go wtime 2000 btime 2000")
+	assert.True(t, IsNil(err))
+
+	fields := strings.Fields(result[len(result)-1])
+	assert.Equal(t, 4, len(fields), result)
+	assert.Equal(t, "bestmove", fields[0])
+	assert.Equal(t, "ponder", fields[2])
+
+	// Think about the expected reply while the opponent is thinking
+	_, err = r.HandleInput(position + " " + fields[1] + " " + fields[3])
+	assert.True(t, IsNil(err))
+
+	result, err = r.HandleInput("go ponder wtime 2000 btime 2000")
+	assert.True(t, IsNil(err))
+	assert.Empty(t, result)
+
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	result, err = r.HandleInput("ponderhit")
+	assert.True(t, IsNil(err))
+	assert.Empty(t, result)
+
+	<-r.SearchDone()
+	result, err = r.FinishSearch()
+	assert.True(t, IsNil(err))
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "), result)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestUciStop(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	_, err := r.HandleInput("position startpos")
+	assert.True(t, IsNil(err))
+
+	result, err := r.HandleInput("go movetime 10000")
+	assert.True(t, IsNil(err))
+	assert.Empty(t, result)
+
+	// Other commands are answered while searching
+	time.Sleep(100 * time.Millisecond)
+	result, err = r.HandleInput("isready")
+	assert.True(t, IsNil(err))
+	assert.Equal(t, []string{"readyok"}, result)
+
+	start := time.Now()
+	result, err = r.HandleInput("stop")
+	assert.True(t, IsNil(err))
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "), result)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Nil(t, r.SearchDone())
+}
+
+func TestUciPonderMiss(t *testing.T) {
+	runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+	r := NewUciRunner(runner)
+
+	_, err := r.HandleInput("position startpos moves e2e4 e7e5")
+	assert.True(t, IsNil(err))
+
+	result, err := r.HandleInput("go ponder wtime 100 btime 100")
+	assert.True(t, IsNil(err))
+	assert.Empty(t, result)
+
+	// The time limits don't apply until the ponderhit
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, r.searching)
+
+	// The opponent played something else
+	result, err = r.HandleInput("stop")
+	assert.True(t, IsNil(err))
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "), result)
+
+	_, err = r.HandleInput("position startpos moves e2e4 e7e5 g1f3")
+	assert.True(t, IsNil(err))
+
+	result, err = handleGo(&r, "go wtime 2000 btime 2000")
+	assert.True(t, IsNil(err))
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "), result)
+
+	// Without a search running, there's nothing to stop
+	result, err = r.HandleInput("stop")
+	assert.True(t, IsNil(err))
+	assert.Empty(t, result)
+}
+
+func TestUciNodes(t *testing.T) {
+	bestMove := func() string {
+		runner := chessgo.NewChessGoRunner(chessgo.ChessGoOptions{})
+		r := NewUciRunner(runner)
+
+		_, err := r.HandleInput("position startpos moves e2e4 e7e5")
+		assert.True(t, IsNil(err))
+
+		result, err := handleGo(&r, "go nodes 5000")
+		assert.True(t, IsNil(err))
+		return result[len(result)-1]
+	}
+
+	first := bestMove()
+	assert.True(t, strings.HasPrefix(first, "bestmove "))
+	assert.Equal(t, first, bestMove())
+}
+
+func TestUciOwnBook(t *testing.T) {
+	games, err := book.ReadPgn(strings.NewReader("1. d4 d5 1-0"))
+	assert.True(t, IsNil(err), err)
+	b, err := book.Build(games, book.BuildOptions{})
+	assert.True(t, IsNil(err), err)
+
+	path := filepath.Join(t.TempDir(), "my book.bin")
+	assert.True(t, IsNil(b.Save(path)))
+
+	name, value, err := parseSetOption("setoption name BookFile value " + path)
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, "bookfile", name)
+	assert.Equal(t, path, value)
+
+	r := NewUciRunner(chessgo.NewChessGoRunner(chessgo.ChessGoOptions{}))
+	for _, input := range []string{
+		"uci",
+		"setoption name OwnBook value true",
+		"setoption name BookFile value " + path,
+		"position startpos",
+	} {
+		_, err := r.HandleInput(input)
+		assert.True(t, IsNil(err), err)
+	}
+
+	result, err := handleGo(&r, "go depth 3")
+	assert.True(t, IsNil(err), err)
+	assert.Equal(t, []string{"bestmove d2d4"}, result)
+
+	_, err = r.HandleInput("setoption name OwnBook value false")
+	assert.True(t, IsNil(err), err)
+	result, err = handleGo(&r, "go depth 3")
+	assert.True(t, IsNil(err), err)
+	assert.True(t, strings.HasPrefix(result[0], "info depth 3"), result)
+}
+
+func TestUciLimitStrength(t *testing.T) {
+	r := NewUciRunner(chessgo.NewChessGoRunner(chessgo.ChessGoOptions{}))
+	result, err := r.HandleInput("uci")
+	assert.True(t, IsNil(err), err)
+	assert.Contains(t, result, "option name Skill Level type spin default 20 min 0 max 20")
+
+	search := func(inputs ...string) string {
+		for _, input := range append(inputs, "position startpos") {
+			_, err := r.HandleInput(input)
+			assert.True(t, IsNil(err), err)
+		}
+		result, err := handleGo(&r, "go depth 3")
+		assert.True(t, IsNil(err), err)
+		return result[0]
+	}
+
+	// The weakest level only searches one ply
+	assert.True(t, strings.HasPrefix(search("setoption name Skill Level value 0"), "info depth 1 "))
+	assert.True(t, strings.HasPrefix(search("setoption name Skill Level value 20"), "info depth 3 "))
+
+	// UCI_Elo is only used with UCI_LimitStrength
+	assert.True(t, strings.HasPrefix(search("setoption name UCI_Elo value 0"), "info depth 3 "))
+	assert.True(t, strings.HasPrefix(search("setoption name UCI_LimitStrength value true"), "info depth 1 "))
+	assert.True(t, strings.HasPrefix(search("setoption name UCI_LimitStrength value false"), "info depth 3 "))
+
+	_, err = r.HandleInput("setoption name UCI_Elo value strong")
+	assert.False(t, IsNil(err))
+}
+
+func TestUciInfo(t *testing.T) {
+	r := NewUciRunner(chessgo.NewChessGoRunner(chessgo.ChessGoOptions{}))
+
+	info := []string{}
+	r.SetInfoOutput(func(line string) {
+		info = append(info, line)
+	})
+
+	_, err := r.HandleInput("position startpos moves e2e4 e7e5")
+	assert.True(t, IsNil(err), err)
+
+	result, err := handleGo(&r, "go depth 3")
+	assert.True(t, IsNil(err), err)
+	assert.True(t, strings.HasPrefix(result[len(result)-1], "bestmove "), result)
+
+	// One line for each depth
+	assert.Equal(t, 3, len(info), info)
+	for i, line := range info {
+		assert.True(t, strings.HasPrefix(line, fmt.Sprintf("info depth %v seldepth ", i+1)), line)
+		assert.Contains(t, line, " multipv 1 score ")
+		assert.Contains(t, line, " nodes ")
+		assert.Contains(t, line, " pv ")
+	}
+}
+
+func TestInfoLines(t *testing.T) {
+	e2e4, e7e5, d2d4 := MoveFromString("e2e4", QuietMove), MoveFromString("e7e5", QuietMove), MoveFromString("d2d4", QuietMove)
+	result := search.SearchResult{
+		PV:       []Move{e2e4, e7e5},
+		Score:    35,
+		Bound:    search.BetaFailLowerBound,
+		Depth:    5,
+		SelDepth: 9,
+		Nodes:    1000,
+		Time:     20 * time.Millisecond,
+		NPS:      50000,
+		Lines: []Pair[int, []Move]{
+			{First: 35, Second: []Move{e2e4, e7e5}},
+			{First: 20, Second: []Move{d2d4}},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"info depth 5 seldepth 9 multipv 1 score cp 35 lowerbound nodes 1000 nps 50000 time 20 pv e2e4 e7e5",
+		"info depth 5 seldepth 9 multipv 2 score cp 20 nodes 1000 nps 50000 time 20 pv d2d4",
+	}, infoLines(result))
+}